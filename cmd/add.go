@@ -11,6 +11,9 @@ var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add commands or substitutions to nostromo",
 	Long:  "Add commands or substitutions to nostromo",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return task.SetScope(scope)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Exit(task.AddInteractive())
 	},
@@ -18,4 +21,6 @@ var addCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(addCmd)
+
+	addCmd.PersistentFlags().StringVar(&scope, "scope", "global", "Manifest to modify (global, local)")
 }
@@ -5,7 +5,6 @@ import (
 	"os"
 	"strings"
 
-	"github.com/pokanop/nostromo/shell"
 	"github.com/pokanop/nostromo/task"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +15,9 @@ var (
 	language    string
 	aliasOnly   bool
 	mode        string
+	dir         string
+	script      string
+	link        bool
 )
 
 // addcmdCmd represents the addcmd command
@@ -39,14 +41,27 @@ available to commands:
   exclusive    Execute this and only this command ignoring parent commands
 
 You can set using -m or --mode when adding a command or globally using:
-  nostromo manifest set mode <mode>`,
+  nostromo manifest set mode <mode>
+
+A command can also run from a working directory set using --dir. Sub
+commands inherit the directory unless they set their own.
+
+Instead of a command or code snippet, a command can run a script file
+using --script. The language is inferred from the shebang or else the
+file extension unless set with --language and arguments are passed as argv.
+Scripts are copied into ~/.nostromo/scripts, or linked using --link,
+while local manifests reference them relative to the manifest.`,
 	Args: addCmdArgs,
 	Run: func(cmd *cobra.Command, args []string) {
+		if len(script) > 0 {
+			os.Exit(task.AddScriptCommand(args[0], script, description, language, link, aliasOnly, mode, dir))
+		}
+
 		var name string
 		if len(args) > 1 {
 			name = args[1]
 		}
-		os.Exit(task.AddCommand(args[0], name, description, code, language, aliasOnly, mode))
+		os.Exit(task.AddCommand(args[0], name, description, code, language, aliasOnly, mode, dir))
 	},
 }
 
@@ -59,6 +74,9 @@ func init() {
 	addcmdCmd.Flags().StringVarP(&language, "language", "l", "", "Language of code snippet (e.g., ruby, python, perl, js)")
 	addcmdCmd.Flags().BoolVarP(&aliasOnly, "alias-only", "a", false, "Add shell alias only, not a nostromo command")
 	addcmdCmd.Flags().StringVarP(&mode, "mode", "m", "", "Set the mode for the command (concatenate, independent, exclusive)")
+	addcmdCmd.Flags().StringVar(&dir, "dir", "", "Working directory to run the command from")
+	addcmdCmd.Flags().StringVarP(&script, "script", "s", "", "Script file to run for this command")
+	addcmdCmd.Flags().BoolVar(&link, "link", false, "Link the script instead of copying it")
 }
 
 func codeValid() bool {
@@ -69,11 +87,20 @@ func addCmdArgs(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("invalid number of arguments")
 	}
+	if len(script) > 0 {
+		if len(args) > 1 || len(code) > 0 {
+			return fmt.Errorf("cannot provide command or code snippet with a script")
+		}
+		return nil
+	}
+	if link {
+		return fmt.Errorf("link requires a script")
+	}
 	if len(args) < 2 && !codeValid() {
-		return fmt.Errorf("must provide command or code snippet")
+		return fmt.Errorf("must provide command, code snippet or script")
 	}
-	if codeValid() && !shell.IsSupportedLanguage(language) {
-		return fmt.Errorf("invalid code snippet and language, must be in [%s]", strings.Join(shell.SupportedLanguages(), ","))
+	if codeValid() && !task.IsSupportedLanguage(language) {
+		return fmt.Errorf("invalid code snippet and language, must be in [%s]", strings.Join(task.SupportedLanguages(), ","))
 	}
 	return nil
 }
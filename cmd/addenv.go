@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// addenvCmd represents the addenv command
+var addenvCmd = &cobra.Command{
+	Use:   "env [key.path] [name] [value]",
+	Short: "Add an environment variable to nostromo manifest",
+	Long: `Add an environment variable to nostromo manifest for a given key path.
+Environment variables are exported before running the command and are
+inherited by all sub commands, e.g., "build ios" and "build android" can
+share BUILD_TYPE from "build" but each set their own SDK_ROOT.
+
+Sub commands can override variables set in parent scopes.`,
+	Args: cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.AddEnv(args[0], args[1], args[2]))
+	},
+}
+
+func init() {
+	addCmd.AddCommand(addenvCmd)
+}
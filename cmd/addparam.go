@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var (
+	paramType        string
+	paramDefault     string
+	paramDescription string
+	paramValues      []string
+	paramOptional    bool
+)
+
+// addparamCmd represents the addparam command
+var addparamCmd = &cobra.Command{
+	Use:   "param [key.path] [name] [options]",
+	Short: "Add a parameter to nostromo manifest",
+	Long: `Add a named and typed parameter to a command in nostromo manifest.
+Parameters are bound in order to the arguments left over after resolving
+the key path and validated before the command is run. A parameter without
+a default value is required unless it's marked --optional, optional
+parameters without a default are bound to an empty value.
+
+Parameters can be referenced in commands with "${name}" placeholders, otherwise
+their values are appended to the end of the command. There are 5 types
+available to parameters:
+
+  string  Any value, this is the default
+  int     Integer values only
+  bool    Boolean values like true, false, 1 or 0
+  enum    One of the values provided with --values
+  path    A file system path with '~' expanded
+
+For example:
+  nostromo add param k8s.logs pod
+  nostromo add param k8s.logs ns -t enum --values dev,prod -D dev`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.AddParam(args[0], args[1], paramType, paramDefault, paramDescription, paramValues, paramOptional))
+	},
+}
+
+func init() {
+	addCmd.AddCommand(addparamCmd)
+
+	// Flags
+	addparamCmd.Flags().StringVarP(&paramType, "type", "t", "string", "Type of the parameter (string, int, bool, enum, path)")
+	addparamCmd.Flags().StringVarP(&paramDefault, "default", "D", "", "Default value making the parameter optional")
+	addparamCmd.Flags().StringVarP(&paramDescription, "description", "d", "", "Description of the parameter")
+	addparamCmd.Flags().StringSliceVarP(&paramValues, "values", "v", nil, "Allowed values for enum parameters")
+	addparamCmd.Flags().BoolVarP(&paramOptional, "optional", "o", false, "Make the parameter optional without a default value")
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// backupsCmd represents the backups command
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "Manage shell startup file backups",
+	Long: `Manage backups of shell startup files like .bashrc and .zshrc.
+A backup is taken before nostromo changes a startup file and a limited
+number are kept per file under ~/.nostromo/backups.
+
+Configure backups with:
+  nostromo manifest set backupDir ~/path/to/backups
+  nostromo manifest set backupCount 20`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printUsage(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupsCmd)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// backupsDiffCmd represents the backups diff command
+var backupsDiffCmd = &cobra.Command{
+	Use:   "diff [id]",
+	Short: "Show changes to a startup file since a backup",
+	Long: `Show a unified diff from a backup to the current startup file.
+Find the id of the backup with:
+  nostromo backups list`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.DiffBackup(args[0]))
+	},
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsDiffCmd)
+}
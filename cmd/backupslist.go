@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// backupsListCmd represents the backups list command
+var backupsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List shell startup file backups",
+	Long: `List backups of shell startup files, newest first.
+Startup files edited outside nostromo since it last changed them
+are reported as well.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ListBackups())
+	},
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsListCmd)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// backupsRestoreCmd represents the backups restore command
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore [id]",
+	Short: "Restore a startup file from a backup",
+	Long: `Restore a startup file from a backup to roll back a broken profile.
+The current startup file is backed up first so restoring can be
+reverted as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.RestoreBackup(args[0]))
+	},
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsRestoreCmd)
+}
@@ -18,12 +18,25 @@ To configure your shell to load completions for each session add to your init fi
 Note that "nostromo init" will add this automatically.
 
 # In ~/.bashrc, ~/.bash_profile or ~/.zshrc
-eval "$(nostromo completion)"`,
+eval "$(nostromo completion)"
+
+# In ~/.config/fish/conf.d/nostromo.fish
+env NOSTROMO_SHELL=fish nostromo completion | source
+
+# In ~/.config/powershell/Microsoft.PowerShell_profile.ps1
+nostromo completion --shell pwsh | Out-String | Invoke-Expression
+
+Nushell can't evaluate completions at startup so "nostromo init" writes
+them to ~/.config/nushell/config.nu each time the manifest changes.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		os.Exit(task.GenerateCompletions(cmd))
+		os.Exit(task.GenerateCompletions(cmd, shellName))
 	},
 }
 
+var shellName string
+
 func init() {
 	rootCmd.AddCommand(completionCmd)
+
+	completionCmd.Flags().StringVar(&shellName, "shell", "", "Shell to generate completions for (bash, zsh, fish, nu, pwsh)")
 }
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var toFormat string
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert manifest to another file format",
+	Long: `Convert the manifest to another file format replacing the original.
+Manifests can be read and written as YAML, JSON or TOML based on the
+file extension.
+
+Supported formats using --to:
+
+  yaml  YAML manifest, the default
+  json  JSON manifest using the same fields as manifest show --json
+  toml  TOML manifest using the same fields as JSON
+
+For example:
+  nostromo manifest convert --to json`,
+	Args: cobra.NoArgs,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return task.SetScope(scope)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ConvertManifest(toFormat))
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVar(&toFormat, "to", "", "Format to convert to (yaml, json, toml)")
+	convertCmd.MarkFlagRequired("to")
+	convertCmd.Flags().StringVar(&scope, "scope", "global", "Manifest to convert (global, local)")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// cpCmd represents the cp command
+var cpCmd = &cobra.Command{
+	Use:   "cp [from.key.path] [to.key.path]",
+	Short: "Copy a command tree",
+	Long: `Copy a command and all commands beneath it to another key path.
+Substitutions, code snippets and other settings are copied along with
+the commands. Missing parents of the destination are created.
+
+For example, to start a new tree from an existing one:
+  nostromo cp build.ios build.tvos
+
+Shell aliases are updated when root commands are created.`,
+	Args: cobra.ExactArgs(2),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return task.SetScope(scope)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.CopyCommand(args[0], args[1]))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cpCmd)
+
+	cpCmd.Flags().StringVar(&scope, "scope", "global", "Manifest to modify (global, local)")
+}
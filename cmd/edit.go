@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit manifest in $EDITOR",
+	Long: `Edit a copy of the manifest in $VISUAL or $EDITOR. The changes are
+validated when the editor exits and the added, removed and changed
+commands are shown before saving them.
+
+If the manifest doesn't parse, the editor is reopened with the errors
+added as comments at the top so broken manifests are never saved.`,
+	Args: cobra.NoArgs,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return task.SetScope(scope)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.EditManifest())
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(editCmd)
+
+	editCmd.Flags().StringVar(&scope, "scope", "global", "Manifest to edit (global, local)")
+}
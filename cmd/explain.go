@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var explainJSON bool
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain [command] [args]",
+	Short: "Explain how a command resolves without running it",
+	Long: `Explain how nostromo resolves a command step by step without running
+it. Shows the key path that matched, the arguments left over, the
+substitutions applied and which scope they came from, params, what each
+scope along the key path contributes based on its mode and the final
+string evaluated by the shell. If a step fails the steps before it are
+shown along with the error.
+
+Flags for nostromo must come before the command, e.g.:
+  nostromo explain --json build ios release`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Explain(args, explainJSON))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	// Flags
+	explainCmd.Flags().BoolVarP(&explainJSON, "json", "j", false, "Print the explanation as JSON")
+	explainCmd.Flags().SetInterspersed(false)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var format string
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export manifest as a shell script, Makefile or justfile",
+	Long: `Export manifest commands to a standalone file that runs them without
+nostromo installed. Substitutions are applied to arguments at run time
+so exported commands behave like they do through nostromo.
+
+Supported formats using --format:
+
+  sh              POSIX shell script dispatching on key path
+  bash-functions  Functions to source in bash for each root command
+  makefile        Makefile with a target per key path, pass args in ARGS
+  just            justfile with a recipe per key path
+
+For example:
+  nostromo manifest export --format makefile > Makefile`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ExportManifest(format))
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVarP(&format, "format", "f", "sh", "Export format (sh, bash-functions, makefile, just)")
+}
@@ -15,7 +15,9 @@ Nostromo config items are saved in the manifest.
 
 Use this command to get keys to examine these settings:
 verbose: true | false
-aliasesOnly: true | false`,
+aliasesOnly: true | false
+backupDir: directory for startup file backups
+backupCount: number of backups kept per startup file`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Exit(task.GetConfig(args[0]))
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show manifest change history",
+	Long: `Show snapshots of manifests taken before each change, newest first.
+Snapshots are kept under ~/.nostromo/history along with the operation
+that changed the manifest.
+
+Use the id of a snapshot to restore it with:
+  nostromo manifest restore [id]`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ShowHistory())
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var (
+	namespace string
+	policy    string
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [path]",
+	Short: "Import commands from another manifest",
+	Long: `Import commands from another manifest file into nostromo manifest.
+The command tree from the file is grafted under the key path provided
+with --as, or at the root if not provided.
+
+Commands that already exist are conflicts and can be resolved with
+a policy using --policy, otherwise you will be prompted to choose one:
+
+  merge      Merge imported commands into existing ones
+  overwrite  Replace existing commands with imported ones
+  skip       Keep existing commands and ignore imported ones
+
+For example:
+  nostromo manifest import ./shared.yaml --as team.shared`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ImportManifest(args[0], namespace, policy))
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVar(&namespace, "as", "", "Key path to import commands under")
+	importCmd.Flags().StringVarP(&policy, "policy", "p", "", "Policy for resolving conflicts (merge, overwrite, skip)")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var (
+	group      bool
+	groupNames map[string]string
+	comment    bool
+	yes        bool
+)
+
+// importAliasesCmd represents the import aliases command
+var importAliasesCmd = &cobra.Command{
+	Use:   "import-aliases",
+	Short: "Import aliases from shell startup files",
+	Long: `Import existing aliases and one line functions from shell startup
+files like .bashrc and .zshrc into nostromo manifest. Anything in the
+nostromo section or already in the manifest is skipped.
+
+Aliases are added as alias only commands and functions as nostromo
+commands. Use --group to group aliases sharing the first word of their
+command under a key path, e.g., kp='kube pods' becomes kube.kp and is
+run with "kube kp". Note that the group becomes a nostromo command so
+prefixes that are executables on PATH or shell builtins aren't grouped
+unless named with --group-name, e.g., --group-name git=g groups
+gs='git status' as g.gs run with "g gs".
+
+Use --comment to comment out the original definitions so they do
+not conflict with the imported ones, a backup is saved first.
+
+For example:
+  nostromo manifest import-aliases --group --group-name git=g --comment`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ImportAliases(group, groupNames, comment, yes))
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(importAliasesCmd)
+
+	importAliasesCmd.Flags().BoolVarP(&group, "group", "g", false, "Group aliases by command prefix into key paths")
+	importAliasesCmd.Flags().StringToStringVarP(&groupNames, "group-name", "n", nil, "Name the group for a prefix, e.g., git=g")
+	importAliasesCmd.Flags().BoolVarP(&comment, "comment", "c", false, "Comment out original aliases in startup files")
+	importAliasesCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Import without confirming")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintJSON   bool
+	lintStrict bool
+	lintIgnore []string
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check manifest for problems",
+	Long: `Check the manifest for problems and exit non-zero if any errors are
+found, or any warnings too using --strict. Issues are printed one per line
+or as a JSON array using --json so dotfiles repositories can lint manifests
+in CI, manifests that can't be parsed are reported as a JSON object with
+an error field instead.
+
+Rules checked:
+
+  key-delimiter          aliases containing the key path delimiter
+  keypath-mismatch       key paths that don't match the command tree
+  empty-leaf             leaf commands with nothing to run
+  unsupported-language   code snippets in unsupported languages
+  missing-script         script files that don't exist
+  shadowed-substitution  substitutions hidden by another scope or command
+  shadowed-command       root aliases shadowing shell builtins or binaries
+  unreachable-mode       commands or snippets that never run due to modes
+  unreachable-child      child commands of alias only commands
+
+For example:
+  nostromo manifest lint --json --ignore shadowed-command`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.LintManifest(lintJSON, lintStrict, lintIgnore))
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().BoolVarP(&lintJSON, "json", "j", false, "Print issues as json")
+	lintCmd.Flags().BoolVarP(&lintStrict, "strict", "s", false, "Exit non-zero on warnings too")
+	lintCmd.Flags().StringSliceVar(&lintIgnore, "ignore", nil, "Rules to skip")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// mvCmd represents the mv command
+var mvCmd = &cobra.Command{
+	Use:   "mv [from.key.path] [to.key.path]",
+	Short: "Move or rename a command tree",
+	Long: `Move or rename a command and all commands beneath it to another
+key path. Substitutions, code snippets and other settings move along
+with the commands. Missing parents of the destination are created.
+
+For example, to rename a command:
+  nostromo mv build.ios build.iphone
+
+Shell aliases are updated when root commands change.`,
+	Args: cobra.ExactArgs(2),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return task.SetScope(scope)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.MoveCommand(args[0], args[1]))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+
+	mvCmd.Flags().StringVar(&scope, "scope", "global", "Manifest to modify (global, local)")
+}
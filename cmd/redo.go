@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// redoCmd represents the redo command
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Redo the last undone manifest change",
+	Long: `Redo the last change undone with undo. Undone changes can only be
+redone until the manifest is changed again.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Redo())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(redoCmd)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/pokanop/nostromo/task"
 	"github.com/spf13/cobra"
 )
 
@@ -9,6 +10,9 @@ var removeCmd = &cobra.Command{
 	Use:   "remove",
 	Short: "Remove commands or substitutions from nostromo",
 	Long:  "Remove commands or substitutions from nostromo",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return task.SetScope(scope)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		printUsage(cmd)
 	},
@@ -16,4 +20,6 @@ var removeCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(removeCmd)
+
+	removeCmd.PersistentFlags().StringVar(&scope, "scope", "global", "Manifest to modify (global, local)")
 }
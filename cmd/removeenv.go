@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// removeenvCmd represents the removeenv command
+var removeenvCmd = &cobra.Command{
+	Use:   "env [key.path] [name]",
+	Short: "Remove an environment variable from nostromo manifest",
+	Long: `Remove an environment variable from nostromo manifest for a given key path.
+Sub commands will inherit the variable from parent scopes if set there.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.RemoveEnv(args[0], args[1]))
+	},
+}
+
+func init() {
+	removeCmd.AddCommand(removeenvCmd)
+}
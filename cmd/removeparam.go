@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// removeparamCmd represents the removeparam command
+var removeparamCmd = &cobra.Command{
+	Use:   "param [key.path] [name]",
+	Short: "Remove a parameter from nostromo manifest",
+	Long: `Remove a named parameter from a command in nostromo manifest.
+Arguments for the command will no longer be validated against it.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.RemoveParam(args[0], args[1]))
+	},
+}
+
+func init() {
+	removeCmd.AddCommand(removeparamCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore [id]",
+	Short: "Restore manifest from history",
+	Long: `Restore a manifest to a snapshot from its change history.
+Find the id of the snapshot to restore with:
+  nostromo manifest history
+
+Restoring is recorded as a change itself so it can be undone.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.RestoreSnapshot(args[0]))
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(restoreCmd)
+}
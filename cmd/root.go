@@ -4,13 +4,17 @@ import (
 	"os"
 
 	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
 	"github.com/pokanop/nostromo/task"
 	"github.com/pokanop/nostromo/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var ver *version.Info
+var (
+	ver   *version.Info
+	scope string
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -69,4 +73,5 @@ func printUsage(cmd *cobra.Command) {
 
 func printVersion() {
 	log.Regularf("Nostromo: %s\n", ver.Formatted())
+	log.Regularf("Manifest schema: %d\n", model.SchemaVersion)
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run [command] [args]",
+	Short: "Run command from manifest",
+	Long: `Run a command from the manifest directly instead of printing it for
+the shell to eval. The command runs using your shell with -c, or sh if
+your shell isn't a POSIX shell, and nostromo exits with its exit code.
+
+Commands that change the shell itself like cd or export need eval, so
+commands opt in to running directly with:
+  nostromo set cmd foo --run
+
+Sub commands inherit the setting and are run with nostromo run when
+called through their shell alias.`,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Run(args))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+}
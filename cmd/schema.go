@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var schemaFormat string
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print JSON Schema for manifests",
+	Long: `Print the JSON Schema describing manifests which editors can use
+for validation and autocompletion. Manifests are validated against it
+when loaded.
+
+YAML manifests use lowercase field names while JSON and TOML manifests
+use the names from manifest show --json, so use --format to pick the
+schema for your manifest.
+
+For example, to use it with the YAML language server:
+  nostromo manifest schema > ~/.nostromo/manifest.schema.json
+
+and add this to the top of the manifest:
+  # yaml-language-server: $schema=manifest.schema.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ShowSchema(schemaFormat))
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(schemaCmd)
+
+	schemaCmd.Flags().StringVarP(&schemaFormat, "format", "f", "yaml", "Manifest format (yaml, json, toml)")
+}
@@ -16,9 +16,11 @@ Nostromo config items are saved in the manifest.
 Use this command to set values for these settings:
   verbose: true | false
   aliasesOnly: true | false
-  mode: concatenate | independent | exclusive`,
+  mode: concatenate | independent | exclusive
+  backupDir: directory for startup file backups (~/.nostromo/backups)
+  backupCount: number of backups kept per startup file (10)`,
 	Args:      cobra.MinimumNArgs(2),
-	ValidArgs: []string{"verbose", "aliasesOnly", "mode"},
+	ValidArgs: []string{"verbose", "aliasesOnly", "mode", "backupDir", "backupCount"},
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Exit(task.SetConfig(args[0], args[1]))
 	},
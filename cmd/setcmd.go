@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var (
+	setName        string
+	setDescription string
+	setCode        string
+	setScript      string
+	setLink        bool
+	setLanguage    string
+	setMode        string
+	setDir         string
+	setRun         bool
+)
+
+// setcmdCmd represents the setcmd command
+var setcmdCmd = &cobra.Command{
+	Use:   "cmd [key.path] [options]",
+	Short: "Update a command in nostromo manifest",
+	Long: `Update a command in nostromo manifest for a given key path.
+Only the fields passed as flags are changed, so a command can be
+changed without losing its description, code or mode:
+  nostromo set cmd foo.bar --command 'echo bar'
+
+A script file set using --script replaces any code snippet and is
+copied, or linked using --link, like it is when adding a command.
+
+Without any flags you will be prompted for each field with the
+current values as defaults.`,
+	Args: setCmdArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		update := &model.CommandUpdate{}
+		flags := cmd.Flags()
+		if flags.Changed("command") {
+			update.Name = &setName
+		}
+		if flags.Changed("description") {
+			update.Description = &setDescription
+		}
+		if flags.Changed("mode") {
+			update.Mode = &setMode
+		}
+		if flags.Changed("code") {
+			update.Snippet = &setCode
+		}
+		if flags.Changed("script") {
+			update.Script = &setScript
+		}
+		if flags.Changed("language") {
+			update.Language = &setLanguage
+		}
+		if flags.Changed("dir") {
+			update.Dir = &setDir
+		}
+		if flags.Changed("run") {
+			update.Run = &setRun
+		}
+
+		if flags.NFlag() == 0 || (flags.NFlag() == 1 && flags.Changed("scope")) {
+			os.Exit(task.SetCommandInteractive(args[0]))
+		}
+		os.Exit(task.SetCommand(args[0], update, setLink))
+	},
+}
+
+func init() {
+	setRootCmd.AddCommand(setcmdCmd)
+
+	// Flags
+	setcmdCmd.Flags().StringVar(&setName, "command", "", "Shell command to run")
+	setcmdCmd.Flags().StringVarP(&setDescription, "description", "d", "", "Description of the command")
+	setcmdCmd.Flags().StringVarP(&setCode, "code", "c", "", "Code snippet to run for this command")
+	setcmdCmd.Flags().StringVarP(&setScript, "script", "s", "", "Script file to run for this command")
+	setcmdCmd.Flags().BoolVar(&setLink, "link", false, "Link the script instead of copying it")
+	setcmdCmd.Flags().StringVarP(&setLanguage, "language", "l", "", "Language of code snippet (e.g., ruby, python, perl, js)")
+	setcmdCmd.Flags().StringVarP(&setMode, "mode", "m", "", "Mode for the command (concatenate, independent, exclusive)")
+	setcmdCmd.Flags().StringVar(&setDir, "dir", "", "Working directory to run the command from")
+	setcmdCmd.Flags().BoolVar(&setRun, "run", false, "Run directly instead of evaluating in the shell, use --run=false to undo")
+}
+
+func setCmdArgs(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("invalid number of arguments")
+	}
+	flags := cmd.Flags()
+	if flags.Changed("script") && flags.Changed("code") {
+		return fmt.Errorf("cannot provide code snippet with a script")
+	}
+	if setLink && !flags.Changed("script") {
+		return fmt.Errorf("link requires a script")
+	}
+	if len(setLanguage) > 0 && !task.IsSupportedLanguage(setLanguage) {
+		return fmt.Errorf("invalid language, must be in [%s]", strings.Join(task.SupportedLanguages(), ","))
+	}
+	return nil
+}
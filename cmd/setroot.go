@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// setRootCmd represents the root set command
+var setRootCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Update commands in nostromo",
+	Long:  "Update existing commands in nostromo",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return task.SetScope(scope)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setRootCmd)
+
+	setRootCmd.PersistentFlags().StringVar(&scope, "scope", "global", "Manifest to modify (global, local)")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var trustList bool
+
+// trustCmd represents the trust command
+var trustCmd = &cobra.Command{
+	Use:   "trust [dir]",
+	Short: "Trust the project manifest in a directory",
+	Long: `Trust the project manifest in a directory, the current one by default.
+
+Project manifests can override any global command so they're ignored until
+trusted, cloning a repository with a .nostromo.yaml changes nothing until
+you've reviewed and trusted it. Manifests created by nostromo with
+--scope local are trusted automatically.
+
+Use --list to show trusted directories and untrust to remove one.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if trustList {
+			os.Exit(task.ShowTrusted())
+		}
+		os.Exit(task.Trust(dirArg(args)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+
+	trustCmd.Flags().BoolVarP(&trustList, "list", "l", false, "List trusted directories")
+}
+
+// dirArg is the first argument or the current directory
+func dirArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "."
+}
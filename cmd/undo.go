@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last manifest change",
+	Long: `Undo the last change to a manifest by restoring the snapshot taken
+before it. Changes can be undone repeatedly going back through history.
+
+Undone changes can be redone until the manifest is changed again.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Undo())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
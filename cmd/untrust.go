@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// untrustCmd represents the untrust command
+var untrustCmd = &cobra.Command{
+	Use:   "untrust [dir]",
+	Short: "Stop trusting the project manifest in a directory",
+	Long: `Stop trusting the project manifest in a directory, the current one by
+default. Its commands are ignored until it's trusted again.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Untrust(dirArg(args)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(untrustCmd)
+}
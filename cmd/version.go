@@ -10,7 +10,8 @@ var versionCmd = &cobra.Command{
 	Short: "Print version of nostromo",
 	Long: `Print version of nostromo
 
-Supplies tag version, commit hash, and date`,
+Supplies tag version, commit hash, and date along with the
+manifest schema version this build reads and writes`,
 	Run: func(cmd *cobra.Command, args []string) {
 		printVersion()
 	},
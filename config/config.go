@@ -6,20 +6,32 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/pokanop/nostromo/model"
 	"github.com/pokanop/nostromo/pathutil"
-	"gopkg.in/yaml.v2"
 )
 
 // Path for standard nostromo config
 const (
 	Path = "~/.nostromo/manifest.yaml"
+
+	// LocalFilename for project-local manifests layered over the global one
+	LocalFilename = ".nostromo.yaml"
+
+	// ScriptsDir beside the global manifest that command scripts are kept in
+	ScriptsDir = "scripts"
+)
+
+// Scopes for choosing which manifest to modify
+const (
+	GlobalScope = "global"
+	LocalScope  = "local"
 )
 
 // Config manages working with nostromo configuration files
-// The file format is YAML this just provides convenience around converting
-// to a manifest
+// The file format is YAML, JSON or TOML based on the extension, this just
+// provides convenience around converting to a manifest
 type Config struct {
 	path     string
 	manifest *model.Manifest
@@ -43,20 +55,114 @@ func Parse(path string) (*Config, error) {
 		return nil, err
 	}
 
-	var m *model.Manifest
-	ext := filepath.Ext(path)
-	if ext == ".yaml" {
-		err = yaml.Unmarshal(b, &m)
-	} else {
-		return nil, fmt.Errorf("invalid file format: %s", ext)
+	m, err := decode(path, b)
+	if err != nil {
+		return nil, err
+	}
+	m.Link()
+	m.SetDir(filepath.Dir(pathutil.Abs(path)))
+
+	return NewConfig(path, m), nil
+}
+
+// FindPath of an existing manifest sharing the name of path with any of the
+// supported extensions, or path itself if none exist
+func FindPath(path string) string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range extensions {
+		if info, err := os.Stat(pathutil.Abs(base + ext)); err == nil && !info.IsDir() {
+			return base + ext
+		}
+	}
+	return path
+}
+
+// FindLocalPaths returns project-local manifests found walking up from dir
+//
+// Paths are ordered from the furthest to the closest so they can be layered
+// over the global manifest in order.
+func FindLocalPaths(dir string) []string {
+	var paths []string
+	dir = pathutil.Abs(dir)
+	for {
+		path := FindPath(filepath.Join(dir, LocalFilename))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			paths = append([]string{path}, paths...)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
 	}
+	return paths
+}
 
+// ParseLayered parses the global config at path and merges trusted
+// project-local manifests found walking up from dir over it
+//
+// The resulting config has no path since it cannot be saved.
+func ParseLayered(path, dir string, trust *Trust) (*Config, error) {
+	var localPaths []string
+	for _, localPath := range FindLocalPaths(dir) {
+		if trust.IsTrusted(filepath.Dir(localPath)) {
+			localPaths = append(localPaths, localPath)
+		}
+	}
+	return parseLayers(path, localPaths)
+}
+
+// ParseTrusted parses the global config at path and merges the project
+// manifests of every trusted directory over it
+//
+// Shells use it to define functions for root commands up front since the
+// directory they're started from says nothing about where commands run.
+func ParseTrusted(path string, trust *Trust) (*Config, error) {
+	var localPaths []string
+	for _, dir := range trust.Dirs() {
+		localPath := FindPath(filepath.Join(dir, LocalFilename))
+		if info, err := os.Stat(localPath); err == nil && !info.IsDir() {
+			localPaths = append(localPaths, localPath)
+		}
+	}
+	return parseLayers(path, localPaths)
+}
+
+// UntrustedPaths of project manifests found walking up from dir that are
+// ignored until trusted
+func UntrustedPaths(dir string, trust *Trust) []string {
+	var paths []string
+	for _, localPath := range FindLocalPaths(dir) {
+		if !trust.IsTrusted(filepath.Dir(localPath)) {
+			paths = append(paths, localPath)
+		}
+	}
+	return paths
+}
+
+// parseLayers merges the local manifests in order over the global one
+func parseLayers(path string, localPaths []string) (*Config, error) {
+	global, err := Parse(path)
 	if err != nil {
 		return nil, err
 	}
+
+	m := global.Manifest()
+	if len(localPaths) > 0 {
+		m.SetSource(GlobalScope)
+	}
+	for _, localPath := range localPaths {
+		local, err := Parse(localPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", localPath, err)
+		}
+		local.Manifest().SetSource(localPath)
+		m.Merge(local.Manifest())
+	}
 	m.Link()
 
-	return NewConfig(path, m), nil
+	return NewConfig("", m), nil
 }
 
 // Path of the config
@@ -79,20 +185,12 @@ func (c *Config) Save() error {
 		return fmt.Errorf("manifest is nil")
 	}
 
-	var b []byte
-	var err error
-	ext := filepath.Ext(c.path)
-	if ext == ".yaml" {
-		b, err = yaml.Marshal(c.manifest)
-	} else {
-		return fmt.Errorf("invalid file format: %s", ext)
-	}
-
+	b, err := encode(c.path, c.manifest)
 	if err != nil {
 		return err
 	}
 
-	err = ioutil.WriteFile(pathutil.Abs(c.path), b, 0644)
+	err = pathutil.WriteFile(c.path, b, 0644)
 	if err != nil {
 		return err
 	}
@@ -132,6 +230,10 @@ func (c *Config) Get(key string) string {
 		return strconv.FormatBool(c.manifest.Config.AliasesOnly)
 	case "mode":
 		return c.manifest.Config.Mode.String()
+	case "backupDir":
+		return c.manifest.Config.BackupDir
+	case "backupCount":
+		return strconv.Itoa(c.manifest.Config.BackupCount)
 	}
 	return "key not found"
 }
@@ -159,6 +261,19 @@ func (c *Config) Set(key, value string) error {
 		}
 		c.manifest.Config.Mode = model.ModeFromString(value)
 		return nil
+	case "backupDir":
+		c.manifest.Config.BackupDir = value
+		return nil
+	case "backupCount":
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if count < 0 {
+			return fmt.Errorf("invalid backup count, must not be negative")
+		}
+		c.manifest.Config.BackupCount = count
+		return nil
 	}
 	return fmt.Errorf("key not found")
 }
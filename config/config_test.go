@@ -1,6 +1,9 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -18,6 +21,9 @@ func TestParse(t *testing.T) {
 		{"bad file contents", "../testdata/bad.yaml", true},
 		{"bad extension", "../testdata/bad.ext", true},
 		{"yaml file format", "../testdata/manifest.yaml", false},
+		{"json file format", "../testdata/manifest.json", false},
+		{"unversioned schema", "../testdata/unversioned.yaml", false},
+		{"newer schema", "../testdata/newer.yaml", true},
 	}
 
 	for _, test := range tests {
@@ -30,6 +36,8 @@ func TestParse(t *testing.T) {
 					t.Errorf("expected no error but got %s", err)
 				} else if c.manifest == nil {
 					t.Errorf("manifest is nil")
+				} else if c.manifest.Schema != model.SchemaVersion {
+					t.Errorf("expected schema %d, actual: %d", model.SchemaVersion, c.manifest.Schema)
 				}
 				if c.Path() != test.path {
 					t.Errorf("path not as expected")
@@ -39,6 +47,116 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseLayered(t *testing.T) {
+	root, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	sub := filepath.Join(root, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	local := model.NewManifest()
+	local.AddCommand("one.two.local", "local", "", &model.Code{}, false, "concatenate", "")
+	if err := NewConfig(filepath.Join(root, LocalFilename), local).Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	trust, err := LoadTrust(filepath.Join(root, "trusted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trust.Add(root)
+
+	tests := []struct {
+		name       string
+		path       string
+		dir        string
+		trust      *Trust
+		expErr     bool
+		expLayers  int
+		expCommand string
+	}{
+		{"missing path", "/does/not/exist/.nostromo", sub, trust, true, 0, ""},
+		{"no local layers", "../testdata/manifest.yaml", "/", trust, false, 0, ""},
+		{"local layer", "../testdata/manifest.yaml", sub, trust, false, 1, "one.two.local"},
+		{"untrusted local layer", "../testdata/manifest.yaml", sub, nil, false, 1, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if layers := FindLocalPaths(test.dir); !test.expErr && len(layers) != test.expLayers {
+				t.Errorf("expected %d layers but got %d", test.expLayers, len(layers))
+			}
+			c, err := ParseLayered(test.path, test.dir, test.trust)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if !test.expErr && len(test.expCommand) == 0 {
+				if c.Manifest().Find("one.two.local") != nil {
+					t.Errorf("expected no commands from local layers")
+				}
+			} else if !test.expErr && len(test.expCommand) > 0 {
+				cmd := c.Manifest().Find(test.expCommand)
+				if cmd == nil || cmd.Source() != filepath.Join(root, LocalFilename) {
+					t.Errorf("expected command from local layer")
+				}
+				if c.Manifest().Find("0-one-alias") == nil {
+					t.Errorf("expected command from global layer")
+				}
+			}
+		})
+	}
+}
+
+func TestParseTrusted(t *testing.T) {
+	root, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{"one", "two"} {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		local := model.NewManifest()
+		local.AddCommand(name, name, "", &model.Code{}, false, "concatenate", "")
+		if err := NewConfig(filepath.Join(dir, LocalFilename), local).Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trust, err := LoadTrust(filepath.Join(root, "trusted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trust.Add(filepath.Join(root, "one"))
+	trust.Add(filepath.Join(root, "missing"))
+
+	c, err := ParseTrusted("../testdata/manifest.yaml", trust)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if c.Manifest().Find("one") == nil {
+		t.Errorf("expected command from trusted layer")
+	}
+	if c.Manifest().Find("two") != nil {
+		t.Errorf("expected no command from untrusted layer")
+	}
+	if c.Manifest().Find("0-one-alias") == nil {
+		t.Errorf("expected command from global layer")
+	}
+	if paths := UntrustedPaths(filepath.Join(root, "two"), trust); len(paths) != 1 {
+		t.Errorf("expected 1 untrusted path but got %d", len(paths))
+	}
+}
+
 func TestSave(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -51,6 +169,9 @@ func TestSave(t *testing.T) {
 		{"no perms", "/tmp/no-perms/.nostromo", fakeManifest(), true},
 		{"bad extension", "/tmp/bad.ext", fakeManifest(), true},
 		{"yaml file format", "/tmp/manifest.yaml", fakeManifest(), false},
+		{"yml file format", "/tmp/manifest.yml", fakeManifest(), false},
+		{"json file format", "/tmp/manifest.json", fakeManifest(), false},
+		{"toml file format", "/tmp/manifest.toml", fakeManifest(), false},
 	}
 
 	for _, test := range tests {
@@ -66,6 +187,63 @@ func TestSave(t *testing.T) {
 	}
 }
 
+func TestSaveParseFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := fakeManifest()
+	m.Config.Verbose = true
+	if err := m.AddEnv("one.two", "FOO", "bar"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := m.AddParam("one.two.three", &model.Param{Name: "env", Type: "string", Default: "dev", Values: []string{"dev", "prod"}}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, ext := range []string{".yaml", ".yml", ".json", ".toml"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(dir, "manifest"+ext)
+			if err := NewConfig(path, m).Save(); err != nil {
+				t.Fatalf("unable to save: %s", err)
+			}
+
+			c, err := Parse(path)
+			if err != nil {
+				t.Fatalf("unable to parse: %s", err)
+			}
+
+			actual := c.Manifest()
+			if actual.AsJSON() != m.AsJSON() {
+				t.Errorf("expected: %s, actual: %s", m.AsJSON(), actual.AsJSON())
+			}
+		})
+	}
+}
+
+func TestFindPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "manifest.yaml")
+	if actual := FindPath(path); actual != path {
+		t.Errorf("expected: %s, actual: %s", path, actual)
+	}
+
+	toml := filepath.Join(dir, "manifest.toml")
+	if err := ioutil.WriteFile(toml, []byte{}, 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if actual := FindPath(path); actual != toml {
+		t.Errorf("expected: %s, actual: %s", toml, actual)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -129,6 +307,8 @@ func TestGet(t *testing.T) {
 		{"verbose", "verbose", "true"},
 		{"aliasesOnly", "aliasesOnly", "true"},
 		{"mode", "mode", "concatenate"},
+		{"backupDir", "backupDir", ""},
+		{"backupCount", "backupCount", "0"},
 	}
 
 	for _, test := range tests {
@@ -163,6 +343,11 @@ func TestSet(t *testing.T) {
 		{"mode independent", "mode", "independent", false, "independent"},
 		{"mode exclusive", "mode", "exclusive", false, "exclusive"},
 		{"mode invalid", "mode", "invalid", true, ""},
+		{"backupDir", "backupDir", "~/backups", false, "~/backups"},
+		{"backupDir default", "backupDir", "", false, ""},
+		{"backupCount", "backupCount", "5", false, "5"},
+		{"backupCount negative", "backupCount", "-1", true, ""},
+		{"backupCount invalid", "backupCount", "many", true, ""},
 	}
 
 	for _, test := range tests {
@@ -188,7 +373,7 @@ func TestKeys(t *testing.T) {
 		config   *Config
 		expected []string
 	}{
-		{"keys", NewConfig("path", fakeManifest()), []string{"verbose", "aliasesOnly", "mode"}},
+		{"keys", NewConfig("path", fakeManifest()), []string{"verbose", "aliasesOnly", "mode", "backupDir", "backupCount"}},
 	}
 
 	for _, test := range tests {
@@ -213,6 +398,8 @@ func TestFields(t *testing.T) {
 				"verbose":     false,
 				"aliasesOnly": false,
 				"mode":        model.ConcatenateMode.String(),
+				"backupDir":   "",
+				"backupCount": 0,
 			},
 		},
 	}
@@ -228,7 +415,7 @@ func TestFields(t *testing.T) {
 
 func fakeManifest() *model.Manifest {
 	m := model.NewManifest()
-	m.AddCommand("one.two.three", "command", "", &model.Code{}, false, "concatenate")
+	m.AddCommand("one.two.three", "command", "", &model.Code{}, false, "concatenate", "")
 	m.AddSubstitution("one.two", "name", "alias")
 	return m
 }
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/pokanop/nostromo/model"
+	"gopkg.in/yaml.v2"
+)
+
+// Formats supported for manifest files
+const (
+	YAMLFormat = "yaml"
+	JSONFormat = "json"
+	TOMLFormat = "toml"
+)
+
+// extensions of manifest files in the order they are searched for
+var extensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// Formats for manifest files
+func Formats() []string {
+	return []string{YAMLFormat, JSONFormat, TOMLFormat}
+}
+
+// IsSupportedFormat returns true if manifests can be read and written in
+// the format
+func IsSupportedFormat(format string) bool {
+	for _, f := range Formats() {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatForPath of a manifest file based on its extension
+func FormatForPath(path string) (string, error) {
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		return YAMLFormat, nil
+	case ".json":
+		return JSONFormat, nil
+	case ".toml":
+		return TOMLFormat, nil
+	default:
+		return "", fmt.Errorf("invalid file format: %s", ext)
+	}
+}
+
+// PathForFormat replaces the extension of a manifest path
+func PathForFormat(path, format string) (string, error) {
+	if !IsSupportedFormat(format) {
+		return "", fmt.Errorf("invalid format, supported formats: [%s]", strings.Join(Formats(), " "))
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "." + format, nil
+}
+
+// decode manifest file contents migrating them to the current schema and
+// validating them against it
+func decode(path string, b []byte) (*model.Manifest, error) {
+	format, err := FormatForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	line := func(p []string) int { return textLine(b, p) }
+
+	var raw map[string]interface{}
+	switch format {
+	case YAMLFormat:
+		err = yaml.Unmarshal(b, &raw)
+	case JSONFormat:
+		err = jsonError(b, json.Unmarshal(b, &raw))
+	case TOMLFormat:
+		var tree *toml.Tree
+		tree, err = toml.LoadBytes(b)
+		if err == nil && len(tree.Keys()) > 0 {
+			raw = tree.ToMap()
+			line = func(p []string) int { return tomlLine(tree, p) }
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	migrated := false
+	if raw != nil {
+		migrated, err = migrate(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validate(raw, format, line); err != nil {
+		return nil, err
+	}
+
+	var m *model.Manifest
+	if format == YAMLFormat {
+		if migrated {
+			b, err = yaml.Marshal(raw)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err = yaml.Unmarshal(b, &m)
+	} else {
+		m, err = decodeRaw(raw)
+	}
+
+	return m, err
+}
+
+// encode manifest in the format for the file at path
+func encode(path string, m *model.Manifest) ([]byte, error) {
+	format, err := FormatForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case JSONFormat:
+		b, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case TOMLFormat:
+		return encodeTOML(m)
+	default:
+		return yaml.Marshal(m)
+	}
+}
+
+// decodeRaw manifest using the JSON field names shared by JSON and TOML
+func decodeRaw(raw map[string]interface{}) (*model.Manifest, error) {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var m *model.Manifest
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// encodeTOML using the JSON field names so manifests can be converted
+// between formats without renaming fields
+func encodeTOML(m *model.Manifest) ([]byte, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var raw map[string]interface{}
+	if err := d.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	tree, err := toml.TreeFromMap(tomlValues(raw).(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := tree.ToTomlString()
+	return []byte(s), err
+}
+
+// tomlValues drops nulls which TOML can't represent and converts JSON
+// numbers to integers where possible
+func tomlValues(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		values := map[string]interface{}{}
+		for k, v := range value {
+			if v != nil {
+				values[k] = tomlValues(v)
+			}
+		}
+		return values
+	case []interface{}:
+		var values []interface{}
+		for _, v := range value {
+			if v != nil {
+				values = append(values, tomlValues(v))
+			}
+		}
+		return values
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	}
+	return v
+}
+
+// jsonError with the line of syntax and type errors
+func jsonError(b []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		return err
+	}
+
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+	return fmt.Errorf("line %d: %s", bytes.Count(b[:offset], []byte("\n"))+1, err)
+}
+
+// tomlLine of the field at path falling back to the closest parent
+func tomlLine(tree *toml.Tree, path []string) int {
+	for i := len(path); i > 0; i-- {
+		if pos := tree.GetPositionPath(path[:i]); !pos.Invalid() {
+			return pos.Line
+		}
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package config
+
+import "testing"
+
+func TestFormatForPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+		expErr   bool
+	}{
+		{"yaml", "manifest.yaml", YAMLFormat, false},
+		{"yml", "manifest.yml", YAMLFormat, false},
+		{"json", "manifest.json", JSONFormat, false},
+		{"toml", "manifest.toml", TOMLFormat, false},
+		{"bad extension", "manifest.ext", "", true},
+		{"no extension", "manifest", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := FormatForPath(test.path)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPathForFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		format   string
+		expected string
+		expErr   bool
+	}{
+		{"yaml to json", "~/.nostromo/manifest.yaml", JSONFormat, "~/.nostromo/manifest.json", false},
+		{"yml to yaml", ".nostromo.yml", YAMLFormat, ".nostromo.yaml", false},
+		{"json to toml", "manifest.json", TOMLFormat, "manifest.toml", false},
+		{"invalid format", "manifest.yaml", "xml", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := PathForFormat(test.path, test.format)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pokanop/nostromo/pathutil"
+	"gopkg.in/yaml.v2"
+)
+
+// HistoryPath for snapshots of manifests taken before they change
+const HistoryPath = "~/.nostromo/history"
+
+// HistoryLimit is the number of snapshots kept, older ones are pruned
+var HistoryLimit = 100
+
+const (
+	snapshotExt    = ".yaml"
+	snapshotIDTime = "20060102-150405.000000000"
+	redoDir        = "redo"
+)
+
+// Snapshot of a manifest before an operation changed it
+type Snapshot struct {
+	ID        string    `yaml:"id"`
+	Timestamp time.Time `yaml:"timestamp"`
+	Operation string    `yaml:"operation"`
+	Path      string    `yaml:"path"`
+
+	// Manifest contents, empty if the manifest didn't exist
+	Manifest string `yaml:"manifest"`
+
+	// Files changed by the same operation, such as the original manifest
+	// when converting formats, so they're undone together
+	Files []*SnapshotFile `yaml:"files,omitempty"`
+}
+
+// SnapshotFile of another manifest changed by an operation
+type SnapshotFile struct {
+	Path string `yaml:"path"`
+
+	// Manifest contents, empty if the manifest didn't exist
+	Manifest string `yaml:"manifest"`
+}
+
+// Keys as ordered list of fields for logging
+func (s *Snapshot) Keys() []string {
+	return []string{"id", "timestamp", "operation", "path"}
+}
+
+// Fields interface for logging
+func (s *Snapshot) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"id":        s.ID,
+		"timestamp": s.Timestamp.Local().Format(time.RFC1123),
+		"operation": s.Operation,
+		"path":      strings.Join(s.Paths(), ", "),
+	}
+}
+
+// Paths of every manifest in the snapshot
+func (s *Snapshot) Paths() []string {
+	paths := []string{s.Path}
+	for _, f := range s.Files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+// History of manifest changes that can be undone, redone and restored
+//
+// Snapshots are recorded before each change. Undoing restores the latest
+// snapshot and moves the current manifest to the redo stack, which is
+// cleared by any other change.
+type History struct {
+	dir string
+}
+
+// NewHistory stored in dir
+func NewHistory(dir string) *History {
+	return &History{pathutil.Abs(dir)}
+}
+
+// Record a snapshot of the manifest at path and any others before operation
+// changes them
+func (h *History) Record(path, operation string, others ...string) error {
+	if _, err := h.snapshot(h.dir, operation, append([]string{path}, others...)); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(filepath.Join(h.dir, redoDir)); err != nil {
+		return err
+	}
+
+	return h.prune()
+}
+
+// Snapshots that can be undone or restored, oldest first
+func (h *History) Snapshots() ([]*Snapshot, error) {
+	return readSnapshots(h.dir)
+}
+
+// Find snapshot by id
+func (h *History) Find(id string) (*Snapshot, error) {
+	snapshots, err := h.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range snapshots {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot not found: %s", id)
+}
+
+// Undo the latest change returning the snapshot that was restored
+func (h *History) Undo() (*Snapshot, error) {
+	return h.move(h.dir, filepath.Join(h.dir, redoDir), "nothing to undo")
+}
+
+// Redo the latest undone change returning the snapshot that was restored
+func (h *History) Redo() (*Snapshot, error) {
+	return h.move(filepath.Join(h.dir, redoDir), h.dir, "nothing to redo")
+}
+
+// Restore the snapshot with id, restoring is itself recorded as a change
+// so it can be undone
+func (h *History) Restore(id string) (*Snapshot, error) {
+	s, err := h.Find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := s.Paths()
+	if err := h.Record(paths[0], "restore "+id, paths[1:]...); err != nil {
+		return nil, err
+	}
+
+	if err := s.restore(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// move the latest snapshot from one stack to the other, the current
+// manifest is saved to the other stack before restoring the snapshot
+func (h *History) move(from, to, emptyMessage string) (*Snapshot, error) {
+	snapshots, err := readSnapshots(from)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, errors.New(emptyMessage)
+	}
+
+	s := snapshots[len(snapshots)-1]
+	if _, err := h.snapshot(to, s.Operation, s.Paths()); err != nil {
+		return nil, err
+	}
+
+	if err := s.restore(); err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(snapshotPath(from, s.ID)); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// snapshot the manifests at paths into dir, the first is the primary one
+func (h *History) snapshot(dir, operation string, paths []string) (*Snapshot, error) {
+	var files []*SnapshotFile
+	for _, path := range paths {
+		path = pathutil.Abs(path)
+		b, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		files = append(files, &SnapshotFile{path, string(b)})
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s := &Snapshot{
+		ID:        now.UTC().Format(snapshotIDTime),
+		Timestamp: now,
+		Operation: operation,
+		Path:      files[0].Path,
+		Manifest:  files[0].Manifest,
+		Files:     files[1:],
+	}
+
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pathutil.WriteFile(snapshotPath(dir, s.ID), out, 0644); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// prune the oldest snapshots over the limit
+func (h *History) prune() error {
+	snapshots, err := h.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(snapshots)-HistoryLimit; i++ {
+		if err := os.Remove(snapshotPath(h.dir, snapshots[i].ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restore every manifest to the snapshot contents
+func (s *Snapshot) restore() error {
+	if err := restoreFile(s.Path, s.Manifest); err != nil {
+		return err
+	}
+	for _, f := range s.Files {
+		if err := restoreFile(f.Path, f.Manifest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreFile at path to contents, removing it if it didn't exist
+func restoreFile(path, contents string) error {
+	if len(contents) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	return pathutil.WriteFile(path, []byte(contents), 0644)
+}
+
+// readSnapshots in dir sorted by id
+func readSnapshots(dir string) ([]*Snapshot, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snapshots []*Snapshot
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), snapshotExt) {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var s *Snapshot
+		if err := yaml.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("%s: %s", f.Name(), err)
+		}
+		if s == nil {
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].ID < snapshots[j].ID
+	})
+
+	return snapshots, nil
+}
+
+func snapshotPath(dir, id string) string {
+	return filepath.Join(dir, id+snapshotExt)
+}
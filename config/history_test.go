@@ -0,0 +1,203 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "manifest.yaml")
+	h := NewHistory(filepath.Join(dir, "history"))
+
+	write := func(contents string) {
+		if err := h.Record(path, "write "+contents); err != nil {
+			t.Fatalf("unable to record: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	write("one")
+	write("two")
+	write("three")
+
+	tests := []struct {
+		name     string
+		action   func() (*Snapshot, error)
+		expected string
+		expErr   bool
+	}{
+		{"undo", h.Undo, "two", false},
+		{"undo again", h.Undo, "one", false},
+		{"redo", h.Redo, "two", false},
+		{"undo after redo", h.Undo, "one", false},
+		{"undo to missing file", h.Undo, "", false},
+		{"nothing to undo", h.Undo, "", true},
+		{"redo from missing file", h.Redo, "one", false},
+		{"redo again", h.Redo, "two", false},
+		{"redo last", h.Redo, "three", false},
+		{"nothing to redo", h.Redo, "three", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.action()
+			if err != nil && !test.expErr {
+				t.Fatalf("expected no error but got %s", err)
+			} else if err == nil && test.expErr {
+				t.Fatalf("expected error but got none")
+			}
+
+			b, _ := ioutil.ReadFile(path)
+			if actual := string(b); actual != test.expected {
+				t.Errorf("expected: %q, actual: %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHistoryRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "manifest.yaml")
+	h := NewHistory(filepath.Join(dir, "history"))
+	for _, contents := range []string{"one", "two", "three"} {
+		if err := h.Record(path, "write "+contents); err != nil {
+			t.Fatalf("unable to record: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	if _, err := h.Undo(); err != nil {
+		t.Fatalf("unable to undo: %s", err)
+	}
+
+	snapshots, err := h.Snapshots()
+	if err != nil || len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d: %v", len(snapshots), err)
+	}
+
+	if _, err := h.Restore("missing"); err == nil {
+		t.Errorf("expected error restoring missing snapshot")
+	}
+
+	s, err := h.Restore(snapshots[1].ID)
+	if err != nil {
+		t.Fatalf("unable to restore: %s", err)
+	}
+	if s.Operation != "write two" {
+		t.Errorf("expected: write two, actual: %s", s.Operation)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "one" {
+		t.Errorf("expected: one, actual: %s", b)
+	}
+
+	if _, err := h.Redo(); err == nil {
+		t.Errorf("expected restore to clear redo history")
+	}
+
+	if _, err := h.Undo(); err != nil {
+		t.Fatalf("unable to undo restore: %s", err)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "two" {
+		t.Errorf("expected: two, actual: %s", b)
+	}
+}
+
+func TestHistoryFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	yamlPath := filepath.Join(dir, "manifest.yaml")
+	jsonPath := filepath.Join(dir, "manifest.json")
+	if err := ioutil.WriteFile(yamlPath, []byte("yaml"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	h := NewHistory(filepath.Join(dir, "history"))
+	if err := h.Record(jsonPath, "convert", yamlPath); err != nil {
+		t.Fatalf("unable to record: %s", err)
+	}
+	if err := ioutil.WriteFile(jsonPath, []byte("json"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.Remove(yamlPath); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	snapshots, err := h.Snapshots()
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("expected 1 snapshot, got %d: %v", len(snapshots), err)
+	}
+
+	tests := []struct {
+		name         string
+		action       func() (*Snapshot, error)
+		expectedYAML string
+		expectedJSON string
+	}{
+		{"undo", h.Undo, "yaml", ""},
+		{"redo", h.Redo, "", "json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.action(); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			b, _ := ioutil.ReadFile(yamlPath)
+			if actual := string(b); actual != test.expectedYAML {
+				t.Errorf("expected yaml: %q, actual: %q", test.expectedYAML, actual)
+			}
+			b, _ = ioutil.ReadFile(jsonPath)
+			if actual := string(b); actual != test.expectedJSON {
+				t.Errorf("expected json: %q, actual: %q", test.expectedJSON, actual)
+			}
+		})
+	}
+}
+
+func TestHistoryPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(limit int) { HistoryLimit = limit }(HistoryLimit)
+	HistoryLimit = 2
+
+	h := NewHistory(dir)
+	for _, op := range []string{"one", "two", "three"} {
+		if err := h.Record(filepath.Join(dir, "manifest.yaml"), op); err != nil {
+			t.Fatalf("unable to record: %s", err)
+		}
+	}
+
+	snapshots, err := h.Snapshots()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Operation != "two" {
+		t.Errorf("expected oldest snapshot to be pruned")
+	}
+}
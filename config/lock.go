@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pokanop/nostromo/pathutil"
+)
+
+// LockPath for the advisory lock shared by nostromo processes
+const LockPath = "~/.nostromo/.lock"
+
+// LockTimeout is how long to wait for another process to release the lock
+var LockTimeout = 10 * time.Second
+
+const lockRetryInterval = 50 * time.Millisecond
+
+// Lock is an advisory lock held around parsing, modifying and saving
+// manifests so concurrent nostromo processes don't clobber each other
+//
+// The lock is taken on a separate file since manifests are replaced on
+// every save.
+type Lock struct {
+	path string
+	f    *os.File
+}
+
+// AcquireLock waits up to timeout to lock the file at path
+func AcquireLock(path string, timeout time.Duration) (*Lock, error) {
+	lockPath := pathutil.Abs(path)
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err = tryLock(f)
+		if err == nil {
+			return &Lock{lockPath, f}, nil
+		}
+		if err != errLocked {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("manifest is locked by another nostromo process, timed out after %s waiting for %s", timeout, lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// Unlock so other processes can proceed
+func (l *Lock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+
+	err := unlock(l.f)
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	l.f = nil
+	return err
+}
@@ -0,0 +1,46 @@
+//go:build !windows
+// +build !windows
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".lock")
+	l, err := AcquireLock(path, time.Second)
+	if err != nil {
+		t.Fatalf("unable to lock: %s", err)
+	}
+
+	if _, err := AcquireLock(path, 100*time.Millisecond); err == nil {
+		t.Errorf("expected lock to time out while held")
+	}
+
+	if err := l.Unlock(); err != nil {
+		t.Fatalf("unable to unlock: %s", err)
+	}
+
+	l, err = AcquireLock(path, time.Second)
+	if err != nil {
+		t.Errorf("expected lock after unlock but got: %s", err)
+	}
+	l.Unlock()
+}
+
+func TestAcquireLockMissingDir(t *testing.T) {
+	if _, err := AcquireLock("/does/not/exist/.lock", time.Second); err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package config
+
+import (
+	"errors"
+	"os"
+)
+
+var errLocked = errors.New("locked")
+
+// Advisory locks are not supported on windows, writes are still atomic
+func tryLock(f *os.File) error {
+	return nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
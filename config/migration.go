@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+// schemaKey holding the schema version in manifest files
+const schemaKey = "schema"
+
+// migration upgrades a raw manifest from one schema version to the next
+type migration func(raw map[string]interface{}) error
+
+// migrations keyed by the schema version they upgrade from
+//
+// Manifests are migrated one version at a time until they reach
+// `model.SchemaVersion`, so each migration only needs to know about the
+// version before it. Versions that only added optional fields have a nil
+// migration since older manifests are valid as is, the schema is bumped so
+// older versions of nostromo refuse manifests that may use them.
+var migrations = map[int]migration{
+	// Commands can run directly with `run`
+	1: nil,
+	// Custom languages can be configured
+	2: nil,
+	// Code can run a script file
+	3: nil,
+	// Params can be optional
+	4: nil,
+}
+
+// migrate a raw manifest to the current schema version returning true if
+// it was changed
+//
+// Manifests written by a newer schema are refused since fields unknown to
+// this version would be dropped when saving.
+func migrate(raw map[string]interface{}) (bool, error) {
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return false, err
+	}
+
+	if version > model.SchemaVersion {
+		writtenBy := "a newer version"
+		if v, ok := raw["version"]; ok {
+			writtenBy = fmt.Sprintf("version %v", v)
+		}
+		return false, fmt.Errorf("manifest schema %d was written by nostromo %s and is newer than schema %d supported by this version, upgrade nostromo to use it", version, writtenBy, model.SchemaVersion)
+	}
+
+	// Unversioned manifests only need their schema recorded
+	migrated := raw[schemaKey] == nil || version < model.SchemaVersion
+	for ; version < model.SchemaVersion; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return false, fmt.Errorf("no migration found for manifest schema %d", version)
+		}
+		if m == nil {
+			continue
+		}
+		if err := m(raw); err != nil {
+			return false, fmt.Errorf("unable to migrate manifest schema %d: %s", version, err)
+		}
+	}
+	raw[schemaKey] = version
+
+	return migrated, nil
+}
+
+// schemaVersion of a raw manifest, manifests without one predate schema
+// versions and share the format of schema 1
+func schemaVersion(raw map[string]interface{}) (int, error) {
+	v, ok := raw[schemaKey]
+	if !ok || v == nil {
+		return 1, nil
+	}
+
+	switch version := v.(type) {
+	case int:
+		return version, nil
+	case int64:
+		return int(version), nil
+	case float64:
+		return int(version), nil
+	}
+	return 0, fmt.Errorf("invalid manifest schema: %v", v)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         map[string]interface{}
+		expMigrated bool
+		expErr      bool
+	}{
+		{"unversioned", map[string]interface{}{"version": "0.3.0"}, true, false},
+		{"current", map[string]interface{}{"schema": model.SchemaVersion}, false, false},
+		{"schema 1", map[string]interface{}{"schema": 1}, true, false},
+		{"json number", map[string]interface{}{"schema": float64(model.SchemaVersion)}, false, false},
+		{"newer", map[string]interface{}{"version": "9.0.0", "schema": model.SchemaVersion + 1}, false, true},
+		{"invalid", map[string]interface{}{"schema": "one"}, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrated, err := migrate(test.raw)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			}
+
+			if migrated != test.expMigrated {
+				t.Errorf("expected migrated: %t, actual: %t", test.expMigrated, migrated)
+			}
+
+			if !test.expErr {
+				if v, _ := schemaVersion(test.raw); v != model.SchemaVersion {
+					t.Errorf("expected schema %d, actual: %d", model.SchemaVersion, v)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateMissing(t *testing.T) {
+	defer func(m map[int]migration) { migrations = m }(migrations)
+	migrations = map[int]migration{}
+
+	if _, err := migrate(map[string]interface{}{"schema": 0}); err == nil {
+		t.Errorf("expected error for missing migration but got none")
+	}
+}
+
+func TestMigrateSteps(t *testing.T) {
+	defer func(m map[int]migration) { migrations = m }(migrations)
+
+	var steps []int
+	migrations = map[int]migration{}
+	for v := 1; v < model.SchemaVersion; v++ {
+		version := v
+		migrations[v] = func(raw map[string]interface{}) error {
+			steps = append(steps, version)
+			return nil
+		}
+	}
+
+	raw := map[string]interface{}{"schema": 1}
+	if _, err := migrate(raw); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if len(steps) != model.SchemaVersion-1 {
+		t.Errorf("expected %d migrations, actual: %v", model.SchemaVersion-1, steps)
+	}
+	for i, step := range steps {
+		if step != i+1 {
+			t.Errorf("expected migrations in order, actual: %v", steps)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+const (
+	schemaDraft = "http://json-schema.org/draft-07/schema#"
+	refPrefix   = "#/definitions/"
+)
+
+// descriptions of manifest fields shown by editors using the schema
+var descriptions = map[string]string{
+	"Manifest":             "nostromo manifest of commands and settings",
+	"Manifest.Version":     "Version of nostromo that last wrote the manifest",
+	"Manifest.Schema":      "Schema version describing the manifest format",
+	"Manifest.Config":      "Settings for nostromo",
+	"Manifest.Commands":    "Root commands keyed by alias",
+	"Config":               "Settings for nostromo",
+	"Config.Verbose":       "Show verbose output",
+	"Config.AliasesOnly":   "Create plain shell aliases for all commands",
+	"Config.Mode":          "Default execution mode for new commands",
+	"Config.BackupDir":     "Directory for startup file backups, defaults to ~/.nostromo/backups",
+	"Config.BackupCount":   "Backups kept per startup file, defaults to 10",
+	"Config.Languages":     "Languages for code snippets in addition to the built in ones",
+	"Language":             "Interpreter for running code snippets",
+	"Language.Name":        "Name used as the language of code snippets",
+	"Language.Interpreter": "Command running the interpreter, may include leading arguments",
+	"Language.Flag":        "Flag passing code inline (e.g. -e), may be empty",
+	"Language.File":        "Always run snippets from a script file passed as the last argument",
+	"Language.Extension":   "Extension for script files (e.g. .lua)",
+	"Command":              "Scope for running one or more commands",
+	"Command.KeyPath":      "Dot separated path to the command in the tree",
+	"Command.Name":         "Command run when the alias is called",
+	"Command.Alias":        "Alias for the command, must match its key",
+	"Command.AliasOnly":    "Create a plain shell alias for the command",
+	"Command.Description":  "Description shown in help",
+	"Command.Commands":     "Child commands keyed by alias",
+	"Command.Subs":         "Substitutions for arguments keyed by alias",
+	"Command.Code":         "Code snippet run instead of the command name",
+	"Command.Mode":         "Execution mode for the command",
+	"Command.Params":       "Named and typed arguments",
+	"Command.Env":          "Environment variables exported before running",
+	"Command.Dir":          "Working directory the command runs in",
+	"Command.Run":          "Run directly instead of evaluating in the shell, inherited by sub commands",
+	"Substitution":         "Argument replaced when running commands in scope",
+	"Substitution.Name":    "Value the alias is replaced with",
+	"Substitution.Alias":   "Argument to replace",
+	"Code":                 "Code snippet in a supported language",
+	"Code.Language":        "Language of the snippet",
+	"Code.Snippet":         "Source code to run",
+	"Code.Script":          "Script file run instead of a snippet, relative to the manifest",
+	"Param":                "Named and typed argument of a command",
+	"Param.Name":           "Name of the param",
+	"Param.Type":           "Type of the param",
+	"Param.Default":        "Default value, params without one are required unless optional",
+	"Param.Optional":       "Param may be omitted without a default, it's bound to an empty value",
+	"Param.Description":    "Description shown in help",
+	"Param.Values":         "Allowed values for enum params",
+	"Mode":                 "Execution mode, 0 concatenate, 1 independent or 2 exclusive",
+}
+
+// Schema of manifests as a JSON Schema with field names for format
+//
+// The schema is generated from the model so it stays in sync with the
+// fields nostromo reads and writes. JSON and TOML manifests share the same
+// field names while YAML uses lowercase names.
+func Schema(format string) map[string]interface{} {
+	g := &schemaGenerator{format: format, definitions: map[string]interface{}{}}
+	s := g.object(reflect.TypeOf(model.Manifest{}))
+	s["$schema"] = schemaDraft
+	s["title"] = "nostromo manifest"
+	s["definitions"] = g.definitions
+	return s
+}
+
+// SchemaJSON for manifests in format as indented JSON
+func SchemaJSON(format string) ([]byte, error) {
+	if !IsSupportedFormat(format) {
+		return nil, fmt.Errorf("invalid format, supported formats: [%s]", strings.Join(Formats(), " "))
+	}
+
+	b, err := json.MarshalIndent(Schema(format), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+type schemaGenerator struct {
+	format      string
+	definitions map[string]interface{}
+}
+
+// object schema for struct type t
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+
+		s := g.value(f.Type, true)
+		if d, ok := descriptions[t.Name()+"."+f.Name]; ok {
+			s = withDescription(s, d)
+		}
+		properties[g.fieldName(f)] = s
+	}
+
+	return map[string]interface{}{
+		"type":        "object",
+		"description": descriptions[t.Name()],
+		"properties":  properties,
+	}
+}
+
+// value schema for type t, nullable values may also be null as written
+// for empty pointers and collections
+func (g *schemaGenerator) value(t reflect.Type, nullable bool) map[string]interface{} {
+	if t == reflect.TypeOf(model.Mode(0)) {
+		if _, ok := g.definitions["mode"]; !ok {
+			var modes []interface{}
+			for _, m := range model.SupportedModes() {
+				modes = append(modes, int(model.ModeFromString(m)))
+			}
+			g.definitions["mode"] = map[string]interface{}{
+				"type":        "integer",
+				"description": descriptions["Mode"],
+				"enum":        modes,
+			}
+		}
+		return map[string]interface{}{"$ref": refPrefix + "mode"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		name := strings.ToLower(t.Elem().Name())
+		if _, ok := g.definitions[name]; !ok {
+			// Placeholder so recursive types like commands refer to themselves
+			g.definitions[name] = map[string]interface{}{}
+			g.definitions[name] = g.object(t.Elem())
+		}
+		ref := map[string]interface{}{"$ref": refPrefix + name}
+		if nullable {
+			return map[string]interface{}{
+				"anyOf": []interface{}{ref, map[string]interface{}{"type": "null"}},
+			}
+		}
+		return ref
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []interface{}{"object", "null"},
+			"additionalProperties": g.value(t.Elem(), false),
+		}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  []interface{}{"array", "null"},
+			"items": g.value(t.Elem(), false),
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// fieldName as encoded in the format, YAML defaults to lowercase names
+func (g *schemaGenerator) fieldName(f reflect.StructField) string {
+	tag := "json"
+	if g.format == YAMLFormat {
+		tag = "yaml"
+	}
+
+	name := strings.Split(f.Tag.Get(tag), ",")[0]
+	if len(name) > 0 {
+		return name
+	}
+	if g.format == YAMLFormat {
+		return strings.ToLower(f.Name)
+	}
+	return f.Name
+}
+
+func withDescription(s map[string]interface{}, description string) map[string]interface{} {
+	if len(description) == 0 {
+		return s
+	}
+	if _, ok := s["$ref"]; ok {
+		// Keywords next to $ref are ignored by draft-07 validators
+		return map[string]interface{}{
+			"description": description,
+			"allOf":       []interface{}{s},
+		}
+	}
+	s["description"] = description
+	return s
+}
+
+// validate raw manifest contents against the schema, line finds the line
+// of a field for errors or returns 0 if unknown
+func validate(raw map[string]interface{}, format string, line func(path []string) int) error {
+	if raw == nil {
+		return errors.New("manifest is empty")
+	}
+
+	s := Schema(format)
+	v := &validator{
+		definitions:   s["definitions"].(map[string]interface{}),
+		scalarStrings: format == YAMLFormat,
+	}
+	v.validate(s, raw, nil)
+	if len(v.issues) == 0 {
+		return nil
+	}
+
+	var messages []string
+	for _, issue := range v.issues {
+		field := strings.Join(issue.path, ".")
+		if len(field) == 0 {
+			field = "manifest"
+		}
+		message := fmt.Sprintf("%s: %s", field, issue.message)
+		if n := line(issue.path); n > 0 {
+			message = fmt.Sprintf("line %d: %s", n, message)
+		}
+		messages = append(messages, message)
+	}
+	return fmt.Errorf("invalid manifest:\n  %s", strings.Join(messages, "\n  "))
+}
+
+type schemaIssue struct {
+	path    []string
+	message string
+}
+
+// validator for the subset of JSON Schema used by the manifest schema
+type validator struct {
+	definitions map[string]interface{}
+	issues      []*schemaIssue
+
+	// scalarStrings accepts any scalar as a string like YAML decoding does
+	scalarStrings bool
+}
+
+func (v *validator) validate(s map[string]interface{}, value interface{}, path []string) {
+	if ref, ok := s["$ref"].(string); ok {
+		v.validate(v.definitions[strings.TrimPrefix(ref, refPrefix)].(map[string]interface{}), value, path)
+		return
+	}
+
+	if allOf, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			v.validate(sub.(map[string]interface{}), value, path)
+		}
+		return
+	}
+
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		var first []*schemaIssue
+		for i, sub := range anyOf {
+			branch := &validator{definitions: v.definitions, scalarStrings: v.scalarStrings}
+			branch.validate(sub.(map[string]interface{}), value, path)
+			if len(branch.issues) == 0 {
+				return
+			}
+			if i == 0 {
+				first = branch.issues
+			}
+		}
+		v.issues = append(v.issues, first...)
+		return
+	}
+
+	if types := schemaTypes(s["type"]); len(types) > 0 {
+		actual := typeOf(value)
+		matched := false
+		for _, t := range types {
+			if t == actual || (t == "number" && actual == "integer") {
+				matched = true
+			} else if t == "string" && v.scalarStrings && isScalar(actual) {
+				matched = true
+			}
+		}
+		if !matched {
+			v.add(path, "expected %s, got %s", strings.Join(types, " or "), actual)
+			return
+		}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				found = true
+			}
+		}
+		if !found {
+			v.add(path, "expected one of %v, got %v", enum, value)
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(s, value, path)
+	case map[interface{}]interface{}:
+		values := map[string]interface{}{}
+		for k, e := range value {
+			values[fmt.Sprint(k)] = e
+		}
+		v.validateObject(s, values, path)
+	case []map[string]interface{}:
+		for i, e := range value {
+			v.validateItem(s, e, path, i)
+		}
+	case []interface{}:
+		for i, e := range value {
+			v.validateItem(s, e, path, i)
+		}
+	}
+}
+
+func (v *validator) validateObject(s map[string]interface{}, value map[string]interface{}, path []string) {
+	properties, _ := s["properties"].(map[string]interface{})
+	additional, _ := s["additionalProperties"].(map[string]interface{})
+
+	var keys []string
+	for k := range value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if p, ok := properties[k].(map[string]interface{}); ok {
+			v.validate(p, value[k], appendPath(path, k))
+		} else if additional != nil {
+			v.validate(additional, value[k], appendPath(path, k))
+		}
+	}
+}
+
+func (v *validator) validateItem(s map[string]interface{}, value interface{}, path []string, i int) {
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		v.validate(items, value, appendPath(path, strconv.Itoa(i)))
+	}
+}
+
+func (v *validator) add(path []string, format string, a ...interface{}) {
+	v.issues = append(v.issues, &schemaIssue{path, fmt.Sprintf(format, a...)})
+}
+
+func appendPath(path []string, key string) []string {
+	p := make([]string, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, key)
+}
+
+func schemaTypes(t interface{}) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, e := range t {
+			types = append(types, e.(string))
+		}
+		return types
+	}
+	return nil
+}
+
+func isScalar(t string) bool {
+	return t == "boolean" || t == "integer" || t == "number"
+}
+
+// typeOf value decoded from YAML, JSON or TOML as a JSON Schema type
+func typeOf(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		if value == float64(int64(value)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}, []map[string]interface{}:
+		return "array"
+	case map[string]interface{}, map[interface{}]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// textLine finds the line of the field at path in YAML or JSON contents by
+// following keys down indented blocks, falling back to the closest parent
+func textLine(b []byte, path []string) int {
+	lines := strings.Split(string(b), "\n")
+	found, indent := -1, -1
+	for _, key := range path {
+		if _, err := strconv.Atoi(key); err == nil && found >= 0 {
+			continue
+		}
+
+		next, childIndent := -1, -1
+		for i := found + 1; i < len(lines); i++ {
+			trimmed := strings.TrimLeft(lines[i], " \t")
+			if len(strings.Trim(trimmed, "{}[], ")) == 0 || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			n := len(lines[i]) - len(trimmed)
+			if strings.HasPrefix(trimmed, "- ") {
+				// Sequence items may sit at the same indent as their key
+				n += 2
+			}
+			if found >= 0 && n <= indent {
+				break
+			}
+
+			// Only direct children match, deeper lines belong to nested
+			// blocks that may reuse the key
+			if childIndent < 0 {
+				childIndent = n
+			}
+			if n == childIndent && isKeyLine(trimmed, key) {
+				next = i
+				indent = n
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		found = next
+	}
+
+	if found < 0 && len(path) > 0 && !strings.Contains(strings.TrimSpace(string(b)), "\n") {
+		// Minified JSON is all on the first line
+		return 1
+	}
+	return found + 1
+}
+
+func isKeyLine(line, key string) bool {
+	line = strings.TrimLeft(line, "- ")
+	for _, k := range []string{key, strconv.Quote(key), "'" + key + "'"} {
+		if strings.HasPrefix(line, k) && strings.HasPrefix(strings.TrimLeft(line[len(k):], " "), ":") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		format string
+	}{
+		{"yaml", "../schema/manifest.schema.json", YAMLFormat},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := ioutil.ReadFile(test.path)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			expected, err := SchemaJSON(test.format)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if string(b) != string(expected) {
+				t.Errorf("%s is out of date, regenerate it with `nostromo manifest schema --format %s`", test.path, test.format)
+			}
+		})
+	}
+}
+
+func TestDecodeValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		contents string
+		expected string
+	}{
+		{"empty yaml", "manifest.yaml", "", "manifest is empty"},
+		{"empty json", "manifest.json", "{}", ""},
+		{"empty toml", "manifest.toml", "", "manifest is empty"},
+		{"valid yaml", "manifest.yaml", "schema: 1\ncommands:\n  foo:\n    name: 42\n    mode: 1\n", ""},
+		{"null command", "manifest.yaml", "schema: 1\ncommands:\n  foo:\n", "line 3: commands.foo: expected object, got null"},
+		{"nested type", "manifest.yaml", "schema: 1\ncommands:\n  foo:\n    commands:\n      bar:\n        code: ruby\n", "line 6: commands.foo.commands.bar.code: expected object, got string"},
+		{"invalid mode", "manifest.yaml", "schema: 1\nconfig:\n  mode: 5\n", "line 3: config.mode: expected one of [0 1 2], got 5"},
+		{"json type", "manifest.json", "{\n  \"schema\": 1,\n  \"commands\": {\n    \"foo\": {\n      \"aliasOnly\": \"yes\"\n    }\n  }\n}\n", "line 5: commands.foo.aliasOnly: expected boolean, got string"},
+		{"json strings", "manifest.json", "{\"schema\": 1, \"commands\": {\"foo\": {\"name\": 42}}}", "line 1: commands.foo.name: expected string, got integer"},
+		{"json syntax", "manifest.json", "{\n  \"schema\": 1,\n}\n", "line 3:"},
+		{"toml type", "manifest.toml", "schema = 1\n\n[commands.foo]\nsubs = 3\n", "line 4: commands.foo.subs: expected object or null, got integer"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decode(test.path, []byte(test.contents))
+			if len(test.expected) == 0 && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if len(test.expected) > 0 && (err == nil || !strings.Contains(err.Error(), test.expected)) {
+				t.Errorf("expected error containing %q but got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestTextLine(t *testing.T) {
+	yamlContents := "config:\n  mode: 0\ncommands:\n  foo:\n    # comment\n    mode: 0\n    params:\n    - name: x\n  build:\n    commands:\n      ios:\n        mode: 1\n    mode: 2\n"
+	jsonContents := "{\n  \"commands\": {\n    \"build\": {\n      \"commands\": {\n        \"ios\": {\n          \"mode\": 1\n        }\n      },\n      \"mode\": 2\n    }\n  }\n}\n"
+	tests := []struct {
+		name     string
+		contents string
+		path     []string
+		expected int
+	}{
+		{"root key", yamlContents, []string{"commands"}, 3},
+		{"nested key", yamlContents, []string{"commands", "foo", "mode"}, 6},
+		{"scoped key", yamlContents, []string{"config", "mode"}, 2},
+		{"array item", yamlContents, []string{"commands", "foo", "params", "0", "name"}, 8},
+		{"key after nested key", yamlContents, []string{"commands", "build", "mode"}, 13},
+		{"key in nested block", yamlContents, []string{"commands", "build", "commands", "ios", "mode"}, 12},
+		{"missing key", yamlContents, []string{"commands", "bar"}, 3},
+		{"missing root", yamlContents, []string{"missing"}, 0},
+		{"json key after nested key", jsonContents, []string{"commands", "build", "mode"}, 9},
+		{"json key in nested block", jsonContents, []string{"commands", "build", "commands", "ios", "mode"}, 6},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := textLine([]byte(test.contents), test.path); actual != test.expected {
+				t.Errorf("expected: %d, actual: %d", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/pathutil"
+)
+
+// TrustPath lists directories whose project manifests are honoured
+const TrustPath = "~/.nostromo/trusted"
+
+// Trust of project directories
+//
+// Project manifests can override any global command so they are ignored
+// until their directory is trusted, otherwise cloning a repository would be
+// enough to change what a command runs.
+type Trust struct {
+	path string
+	dirs map[string]bool
+}
+
+// LoadTrust from the file at path, a missing file trusts nothing
+func LoadTrust(path string) (*Trust, error) {
+	t := &Trust{path, map[string]bool{}}
+
+	b, err := ioutil.ReadFile(pathutil.Abs(path))
+	if os.IsNotExist(err) {
+		return t, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		if dir := strings.TrimSpace(line); len(dir) > 0 {
+			t.dirs[dir] = true
+		}
+	}
+	return t, nil
+}
+
+// Dirs trusted in sorted order
+func (t *Trust) Dirs() []string {
+	var dirs []string
+	if t == nil {
+		return dirs
+	}
+	for dir := range t.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// IsTrusted returns true if project manifests in dir are honoured
+func (t *Trust) IsTrusted(dir string) bool {
+	return t != nil && t.dirs[trustDir(dir)]
+}
+
+// Add dir to the trusted directories
+func (t *Trust) Add(dir string) {
+	t.dirs[trustDir(dir)] = true
+}
+
+// Remove dir from the trusted directories returning false if it wasn't
+func (t *Trust) Remove(dir string) bool {
+	dir = trustDir(dir)
+	if !t.dirs[dir] {
+		return false
+	}
+	delete(t.dirs, dir)
+	return true
+}
+
+// Save trusted directories to file
+func (t *Trust) Save() error {
+	var b strings.Builder
+	for _, dir := range t.Dirs() {
+		b.WriteString(dir + "\n")
+	}
+	return pathutil.WriteFile(t.path, []byte(b.String()), 0600)
+}
+
+// trustDir resolves dir to an absolute path without links so it matches
+// however it's reached
+func trustDir(dir string) string {
+	dir = pathutil.Abs(dir)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return dir
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTrust(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, _ = filepath.EvalSymlinks(dir)
+
+	path := filepath.Join(dir, "trusted")
+	trust, err := LoadTrust(path)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if trust.IsTrusted(dir) {
+		t.Errorf("expected nothing trusted before adding")
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	trust.Add(link)
+	trust.Add(filepath.Join(dir, "other"))
+	if err := trust.Save(); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	trust, err = LoadTrust(path)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if want := []string{dir, filepath.Join(dir, "other")}; !reflect.DeepEqual(trust.Dirs(), want) {
+		t.Errorf("expected %v but got %v", want, trust.Dirs())
+	}
+	if !trust.IsTrusted(dir) || !trust.IsTrusted(link) {
+		t.Errorf("expected dir to be trusted through links")
+	}
+	if !trust.Remove(link) || trust.Remove(link) {
+		t.Errorf("expected dir to be removed once")
+	}
+	if trust.IsTrusted(dir) {
+		t.Errorf("expected dir to no longer be trusted")
+	}
+}
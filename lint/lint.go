@@ -0,0 +1,265 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/shell"
+)
+
+// Severity of an issue
+const (
+	ErrorSeverity   = "error"
+	WarningSeverity = "warning"
+)
+
+// Rules checked by the linter
+const (
+	// KeyDelimiterRule flags aliases containing the key path delimiter
+	KeyDelimiterRule = "key-delimiter"
+
+	// KeyPathMismatchRule flags key paths that don't match the tree
+	KeyPathMismatchRule = "keypath-mismatch"
+
+	// EmptyLeafRule flags leaf commands with nothing to run
+	EmptyLeafRule = "empty-leaf"
+
+	// UnsupportedLanguageRule flags code snippets that can't be run
+	UnsupportedLanguageRule = "unsupported-language"
+
+	// MissingScriptRule flags script files that don't exist
+	MissingScriptRule = "missing-script"
+
+	// ShadowedSubstitutionRule flags substitutions hidden by another scope
+	ShadowedSubstitutionRule = "shadowed-substitution"
+
+	// ShadowedCommandRule flags root aliases hiding builtins or binaries
+	ShadowedCommandRule = "shadowed-command"
+
+	// UnreachableModeRule flags commands that never run due to modes
+	UnreachableModeRule = "unreachable-mode"
+
+	// UnreachableChildRule flags child commands of plain shell aliases
+	UnreachableChildRule = "unreachable-child"
+)
+
+// builtins of common shells that root commands would shadow
+var builtins = []string{
+	".", ":", "[", "alias", "bg", "bind", "break", "builtin", "caller", "case",
+	"cd", "command", "compgen", "complete", "continue", "declare", "dirs",
+	"disown", "do", "done", "echo", "elif", "else", "enable", "esac", "eval",
+	"exec", "exit", "export", "false", "fc", "fg", "fi", "for", "function",
+	"getopts", "hash", "help", "history", "if", "in", "jobs", "kill", "let",
+	"local", "logout", "popd", "printf", "pushd", "pwd", "read", "readonly",
+	"return", "select", "set", "shift", "shopt", "source", "suspend", "test",
+	"then", "time", "times", "trap", "true", "type", "typeset", "ulimit",
+	"umask", "unalias", "unset", "until", "wait", "while",
+}
+
+// lookPath finds binaries shadowed by root commands
+var lookPath = exec.LookPath
+
+// Issue found in a manifest
+type Issue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	KeyPath  string `json:"keyPath"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (i *Issue) String() string {
+	s := fmt.Sprintf("%s: %s (%s)", i.Severity, i.Message, i.Rule)
+
+	// Issues at the root of the manifest have no key path
+	var location []string
+	for _, l := range []string{i.Source, i.KeyPath} {
+		if len(l) > 0 {
+			location = append(location, l)
+		}
+	}
+	if len(location) > 0 {
+		s = strings.Join(location, ":") + ": " + s
+	}
+	return s
+}
+
+// HasErrors returns true if any issue is an error rather than a warning
+func HasErrors(issues []*Issue) bool {
+	for _, i := range issues {
+		if i.Severity == ErrorSeverity {
+			return true
+		}
+	}
+	return false
+}
+
+// Manifest issues sorted by key path, rules in ignore are skipped
+func Manifest(m *model.Manifest, ignore []string) []*Issue {
+	l := &linter{ignore: ignore}
+	if m == nil {
+		return nil
+	}
+
+	for _, key := range sortedKeys(m.Commands) {
+		l.command(key, m.Commands[key], nil)
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].KeyPath < l.issues[j].KeyPath
+	})
+	return l.issues
+}
+
+type linter struct {
+	ignore []string
+	issues []*Issue
+}
+
+// command checks the command found under key and its children, ancestors
+// are ordered from the closest
+func (l *linter) command(key string, c *model.Command, ancestors []*model.Command) {
+	if c == nil {
+		return
+	}
+
+	expected := key
+	if len(ancestors) > 0 {
+		expected = keypath.KeyPath([]string{ancestors[0].KeyPath, key})
+	}
+
+	if strings.Contains(key, keypath.Delimiter) || strings.Contains(c.Alias, keypath.Delimiter) {
+		l.add(c, KeyDelimiterRule, ErrorSeverity, "alias %q contains the key path delimiter %q", c.Alias, keypath.Delimiter)
+	}
+	if c.Alias != key {
+		l.add(c, KeyPathMismatchRule, ErrorSeverity, "alias %q doesn't match its key %q", c.Alias, key)
+	}
+	if c.KeyPath != expected {
+		l.add(c, KeyPathMismatchRule, ErrorSeverity, "key path doesn't match its position %q", expected)
+	}
+
+	hasCode := c.Code != nil && (len(c.Code.Snippet) > 0 || len(c.Code.Script) > 0)
+	if len(c.Commands) == 0 && len(strings.TrimSpace(c.Name)) == 0 && !hasCode {
+		l.add(c, EmptyLeafRule, WarningSeverity, "leaf command has no command or code snippet to run")
+	}
+
+	if c.Code != nil && len(c.Code.Language) > 0 && !shell.IsSupportedLanguage(c.Code.Language) {
+		l.add(c, UnsupportedLanguageRule, ErrorSeverity, "language %q is not supported, supported languages: %s", c.Code.Language, shell.SupportedLanguages())
+	}
+
+	if script := c.Code.ScriptPath(); len(script) > 0 {
+		if _, err := os.Stat(script); err != nil {
+			l.add(c, MissingScriptRule, ErrorSeverity, "script %s doesn't exist", script)
+		}
+	}
+
+	l.substitutions(c, ancestors)
+
+	if len(ancestors) == 0 {
+		l.shadowedCommand(c, key)
+		if shell.IsPlainAlias(c) && len(c.Commands) > 0 {
+			l.add(c, UnreachableChildRule, WarningSeverity, "alias only command is a plain shell alias, its child commands %s can't be run", strings.Join(sortedKeys(c.Commands), ", "))
+		}
+	}
+
+	l.modes(c, ancestors, hasCode)
+
+	ancestors = append([]*model.Command{c}, ancestors...)
+	for _, k := range sortedKeys(c.Commands) {
+		l.command(k, c.Commands[k], ancestors)
+	}
+}
+
+// substitutions hidden by closer scopes or by child commands with the same
+// alias since arguments match commands first
+func (l *linter) substitutions(c *model.Command, ancestors []*model.Command) {
+	for _, alias := range sortedSubs(c.Subs) {
+		for _, a := range ancestors {
+			if _, ok := a.Subs[alias]; ok {
+				l.add(c, ShadowedSubstitutionRule, WarningSeverity, "substitution %q shadows the substitution in parent scope %q", alias, a.KeyPath)
+				break
+			}
+		}
+		if _, ok := c.Commands[alias]; ok {
+			l.add(c, ShadowedSubstitutionRule, WarningSeverity, "substitution %q is shadowed by the child command with the same alias", alias)
+		}
+	}
+}
+
+// shadowedCommand checks root commands which become shell aliases
+func (l *linter) shadowedCommand(c *model.Command, alias string) {
+	for _, b := range builtins {
+		if alias == b {
+			l.add(c, ShadowedCommandRule, WarningSeverity, "alias %q shadows a shell builtin", alias)
+			return
+		}
+	}
+
+	if path, err := lookPath(alias); err == nil {
+		l.add(c, ShadowedCommandRule, WarningSeverity, "alias %q shadows %s on PATH", alias, path)
+	}
+}
+
+// modes that keep a command or its code snippet from running
+func (l *linter) modes(c *model.Command, ancestors []*model.Command, hasCode bool) {
+	if c.Mode == model.ExclusiveMode {
+		if hasCode {
+			l.add(c, UnreachableModeRule, WarningSeverity, "exclusive mode only runs the command name, the code snippet never runs")
+		} else if len(strings.TrimSpace(c.Name)) == 0 {
+			l.add(c, UnreachableModeRule, WarningSeverity, "exclusive mode ignores parent commands and there is no command to run")
+		}
+		return
+	}
+
+	if len(strings.TrimSpace(c.Name)) == 0 {
+		return
+	}
+	for _, a := range ancestors {
+		if a.Code != nil && len(a.Code.Snippet) > 0 {
+			l.add(c, UnreachableModeRule, WarningSeverity, "command is appended to the code snippet in %q, use exclusive mode to run it on its own", a.KeyPath)
+			return
+		}
+		if a.Mode == model.ExclusiveMode {
+			return
+		}
+	}
+}
+
+func (l *linter) add(c *model.Command, rule, severity, format string, a ...interface{}) {
+	for _, r := range l.ignore {
+		if r == rule {
+			return
+		}
+	}
+
+	l.issues = append(l.issues, &Issue{
+		Rule:     rule,
+		Severity: severity,
+		KeyPath:  c.KeyPath,
+		Source:   c.Source(),
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func sortedKeys(commands map[string]*model.Command) []string {
+	var keys []string
+	for k := range commands {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSubs(subs map[string]*model.Substitution) []string {
+	var keys []string
+	for k := range subs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,188 @@
+package lint
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestManifest(t *testing.T) {
+	lookPath = func(file string) (string, error) {
+		if file == "git" {
+			return "/usr/bin/git", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	tests := []struct {
+		name     string
+		commands map[string]*model.Command
+		ignore   []string
+		expected []string
+	}{
+		{"no commands", nil, nil, nil},
+		{"valid commands", map[string]*model.Command{
+			"one": fakeCommand("one", "echo", fakeCommand("one.two", "two")),
+		}, nil, nil},
+		{"key delimiter", map[string]*model.Command{
+			"a.b": withAlias(fakeCommand("a.b", "echo"), "a.b"),
+		}, nil, []string{KeyDelimiterRule}},
+		{"alias mismatch", map[string]*model.Command{
+			"one": fakeCommand("two", "echo"),
+		}, nil, []string{KeyPathMismatchRule, KeyPathMismatchRule}},
+		{"keypath mismatch", map[string]*model.Command{
+			"one": fakeCommand("one", "echo", fakeCommand("other.two", "two")),
+		}, nil, []string{KeyPathMismatchRule}},
+		{"empty leaf", map[string]*model.Command{
+			"one": fakeCommand("one", ""),
+		}, nil, []string{EmptyLeafRule}},
+		{"unsupported language", map[string]*model.Command{
+			"one": withCode(fakeCommand("one", ""), "cobol", "DISPLAY 'HI'"),
+		}, nil, []string{UnsupportedLanguageRule}},
+		{"script", map[string]*model.Command{
+			"one": withScript(fakeCommand("one", ""), "lint_test.go"),
+		}, nil, nil},
+		{"missing script", map[string]*model.Command{
+			"one": withScript(fakeCommand("one", ""), "missing.py"),
+		}, nil, []string{MissingScriptRule}},
+		{"shadowed substitution", map[string]*model.Command{
+			"one": withSub(fakeCommand("one", "echo", withSub(fakeCommand("one.two", "two"), "x")), "x"),
+		}, nil, []string{ShadowedSubstitutionRule}},
+		{"substitution shadowed by command", map[string]*model.Command{
+			"one": withSub(fakeCommand("one", "echo", fakeCommand("one.two", "two")), "two"),
+		}, nil, []string{ShadowedSubstitutionRule}},
+		{"shadowed builtin", map[string]*model.Command{
+			"cd": fakeCommand("cd", "echo"),
+		}, nil, []string{ShadowedCommandRule}},
+		{"shadowed binary", map[string]*model.Command{
+			"git": fakeCommand("git", "git", fakeCommand("git.st", "status")),
+		}, nil, []string{ShadowedCommandRule}},
+		{"ignored rule", map[string]*model.Command{
+			"git": fakeCommand("git", "git"),
+		}, []string{ShadowedCommandRule}, nil},
+		{"exclusive mode with code", map[string]*model.Command{
+			"one": withMode(withCode(fakeCommand("one", "echo"), "ruby", "puts 1"), model.ExclusiveMode),
+		}, nil, []string{UnreachableModeRule}},
+		{"exclusive mode without command", map[string]*model.Command{
+			"one": fakeCommand("one", "echo", withMode(fakeCommand("one.two", ""), model.ExclusiveMode)),
+		}, nil, []string{EmptyLeafRule, UnreachableModeRule}},
+		{"command after code snippet", map[string]*model.Command{
+			"one": withCode(fakeCommand("one", "", fakeCommand("one.two", "two")), "python", "print(1)"),
+		}, nil, []string{UnreachableModeRule}},
+		{"alias only with children", map[string]*model.Command{
+			"one": withAliasOnly(fakeCommand("one", "echo", fakeCommand("one.two", "two"))),
+		}, nil, []string{UnreachableChildRule}},
+		{"alias only leaf", map[string]*model.Command{
+			"one": withAliasOnly(fakeCommand("one", "echo")),
+		}, nil, nil},
+		{"exclusive command after code snippet", map[string]*model.Command{
+			"one": withCode(fakeCommand("one", "", withMode(fakeCommand("one.two", "two"), model.ExclusiveMode)), "python", "print(1)"),
+		}, nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := model.NewManifest()
+			m.Commands = test.commands
+
+			var rules []string
+			for _, issue := range Manifest(m, test.ignore) {
+				rules = append(rules, issue.Rule)
+			}
+			if !reflect.DeepEqual(rules, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, rules)
+			}
+		})
+	}
+}
+
+func fakeCommand(keyPath, name string, children ...*model.Command) *model.Command {
+	c := &model.Command{
+		KeyPath:  keyPath,
+		Name:     name,
+		Commands: map[string]*model.Command{},
+		Subs:     map[string]*model.Substitution{},
+	}
+	c.Alias = keyPath[strings.LastIndex(keyPath, ".")+1:]
+	for _, child := range children {
+		c.Commands[child.Alias] = child
+	}
+	return c
+}
+
+func withAlias(c *model.Command, alias string) *model.Command {
+	c.Alias = alias
+	return c
+}
+
+func withAliasOnly(c *model.Command) *model.Command {
+	c.AliasOnly = true
+	return c
+}
+
+func withCode(c *model.Command, language, snippet string) *model.Command {
+	c.Code = &model.Code{Language: language, Snippet: snippet}
+	return c
+}
+
+func withScript(c *model.Command, script string) *model.Command {
+	c.Code = &model.Code{Language: "python", Script: script}
+	return c
+}
+
+func withSub(c *model.Command, alias string) *model.Command {
+	c.Subs[alias] = &model.Substitution{Name: "value", Alias: alias}
+	return c
+}
+
+func withMode(c *model.Command, mode model.Mode) *model.Command {
+	c.Mode = mode
+	return c
+}
+
+func TestIssueString(t *testing.T) {
+	tests := []struct {
+		name     string
+		issue    *Issue
+		expected string
+	}{
+		{"key path", &Issue{Rule: EmptyLeafRule, Severity: WarningSeverity, KeyPath: "one.two", Message: "empty"}, "one.two: warning: empty (empty-leaf)"},
+		{"source", &Issue{Rule: EmptyLeafRule, Severity: WarningSeverity, KeyPath: "one", Source: "local", Message: "empty"}, "local:one: warning: empty (empty-leaf)"},
+		{"root", &Issue{Rule: KeyPathMismatchRule, Severity: ErrorSeverity, Message: "mismatch"}, "error: mismatch (keypath-mismatch)"},
+		{"root with source", &Issue{Rule: KeyPathMismatchRule, Severity: ErrorSeverity, Source: "local", Message: "mismatch"}, "local: error: mismatch (keypath-mismatch)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.issue.String(); actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	warning := &Issue{Severity: WarningSeverity}
+	err := &Issue{Severity: ErrorSeverity}
+
+	tests := []struct {
+		name     string
+		issues   []*Issue
+		expected bool
+	}{
+		{"no issues", nil, false},
+		{"warnings", []*Issue{warning, warning}, false},
+		{"errors", []*Issue{warning, err}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := HasErrors(test.issues); actual != test.expected {
+				t.Errorf("expected: %t, actual: %t", test.expected, actual)
+			}
+		})
+	}
+}
@@ -1,11 +1,34 @@
 package model
 
+import (
+	"path/filepath"
+
+	"github.com/pokanop/nostromo/pathutil"
+)
+
 // Code container for snippet
 type Code struct {
+	baseDir  string
 	Language string `json:"language"`
 	Snippet  string `json:"snippet"`
+
+	// Script file run instead of a snippet, relative paths are resolved
+	// against the manifest's directory
+	Script string `json:"script,omitempty" yaml:",omitempty"`
 }
 
 func (c *Code) valid() bool {
-	return c != nil && len(c.Snippet) > 0 && len(c.Language) > 0
+	return c != nil && len(c.Language) > 0 && (len(c.Snippet) > 0 || len(c.Script) > 0)
+}
+
+// ScriptPath of the script file or empty if the code is a snippet
+func (c *Code) ScriptPath() string {
+	if c == nil || len(c.Script) == 0 {
+		return ""
+	}
+	path := pathutil.Expand(c.Script)
+	if filepath.IsAbs(path) || len(c.baseDir) == 0 {
+		return path
+	}
+	return filepath.Join(c.baseDir, path)
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/spf13/cobra"
 	"sort"
@@ -13,6 +14,7 @@ import (
 // Command is a scope for running one or more commands
 type Command struct {
 	parent      *Command
+	source      string
 	KeyPath     string                   `json:"keyPath"`
 	Name        string                   `json:"name"`
 	Alias       string                   `json:"alias"`
@@ -22,6 +24,13 @@ type Command struct {
 	Subs        map[string]*Substitution `json:"subs"`
 	Code        *Code                    `json:"code"`
 	Mode        Mode                     `json:"mode"`
+	Params      []*Param                 `json:"params,omitempty" yaml:",omitempty"`
+	Env         map[string]string        `json:"env,omitempty" yaml:",omitempty"`
+	Dir         string                   `json:"dir,omitempty" yaml:",omitempty"`
+
+	// Run the command directly instead of evaluating it in the shell, sub
+	// commands inherit it
+	Run bool `json:"run,omitempty" yaml:",omitempty"`
 }
 
 func (c *Command) String() string {
@@ -30,7 +39,7 @@ func (c *Command) String() string {
 
 // Keys as ordered list of fields for logging
 func (c *Command) Keys() []string {
-	return []string{"keypath", "alias", "command", "description", "commands", "substitutions", "code", "mode", "aliasOnly"}
+	return []string{"keypath", "alias", "command", "description", "commands", "substitutions", "code", "mode", "aliasOnly", "params", "env", "dir", "source"}
 }
 
 // Fields interface for logging
@@ -45,6 +54,10 @@ func (c *Command) Fields() map[string]interface{} {
 		"code":          c.Code.valid(),
 		"mode":          c.Mode.String(),
 		"aliasOnly":     c.AliasOnly,
+		"params":        joinedParams(c.Params),
+		"env":           joinedEnv(c.Env),
+		"dir":           c.Dir,
+		"source":        c.source,
 	}
 }
 
@@ -67,17 +80,56 @@ func (c *Command) Walk(fn func(*Command, *bool)) {
 	c.forwardWalk(fn)
 }
 
+// signature of the command's own fields to compare it without sub commands
+func (c *Command) signature() string {
+	cmd := *c
+	cmd.Commands = nil
+	b, err := json.Marshal(&cmd)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Source of the manifest layer this command came from
+func (c *Command) Source() string {
+	return c.source
+}
+
 // CobraCommand returns a cobra.Command for this command
 func (c *Command) CobraCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:       c.Alias,
+		Use:       c.usage(),
 		Short:     c.Description,
-		Long:      c.Description,
+		Long:      c.longDescription(),
 		ValidArgs: c.commandList(),
 	}
 	for _, childCmd := range c.Commands {
 		cmd.AddCommand(childCmd.CobraCommand())
 	}
+
+	// Complete declared params by position
+	for i, p := range c.Params {
+		switch ParamTypeFromString(p.Type) {
+		case EnumParam:
+			if len(p.Values) > 0 {
+				cmd.MarkZshCompPositionalArgumentWords(i+1, p.Values...)
+			}
+		case BoolParam:
+			cmd.MarkZshCompPositionalArgumentWords(i+1, "true", "false")
+		case PathParam:
+			cmd.MarkZshCompPositionalArgumentFile(i + 1)
+		}
+	}
+
+	// Bash only supports completing the first argument
+	if len(c.Commands) == 0 && len(c.Params) > 0 {
+		p := c.Params[0]
+		if ParamTypeFromString(p.Type) == EnumParam {
+			cmd.ValidArgs = p.Values
+		}
+	}
+
 	return cmd
 }
 
@@ -210,19 +262,104 @@ func (c *Command) shortestKeyPath(keyPath string) string {
 }
 
 // executionString to run the command with provided arguments
-func (c *Command) executionString(args []string) string {
-	var cmd string
-	if c.Mode == ExclusiveMode { // Only run this command
-		cmd = c.Name
-	} else {
-		cmd = c.expand()
+//
+// Arguments are substituted, validated against declared params and then
+// bound to any placeholders in the command string, or appended to the end
+// if there are none.
+func (c *Command) executionString(args []string) (string, error) {
+	values, err := c.executionValues(args)
+	if err != nil {
+		return "", err
 	}
-	subs := []string{}
+
+	return bindPlaceholders(c.baseCommand(), values, paramPositions(c.Params))
+}
+
+// executionArgs like executionString returning arguments not bound to any
+// placeholder separately so they can be passed to scripts as argv
+func (c *Command) executionArgs(args []string) (string, []string, error) {
+	values, err := c.executionValues(args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return bindPlaceholderArgs(c.baseCommand(), values, paramPositions(c.Params))
+}
+
+func (c *Command) executionValues(args []string) ([]string, error) {
+	values, _, err := c.resolveArgs(args)
+	return values, err
+}
+
+// resolveArgs applies substitutions to args and binds them to params,
+// returning the values along with the substitutions made
+//
+// Values bound before an error are returned with it.
+func (c *Command) resolveArgs(args []string) ([]string, []*ExplainedSubstitution, error) {
+	subs := []*ExplainedSubstitution{}
+	values := []string{}
 	for _, arg := range args {
-		subs = append(subs, c.substitute(arg))
+		value, scope := c.substitution(arg)
+		if scope != nil {
+			subs = append(subs, &ExplainedSubstitution{arg, value, scope.KeyPath})
+		}
+		values = append(values, value)
+	}
+
+	values, err := bindParams(c.Params, values)
+	return values, subs, err
+}
+
+// runsCode returns true for code snippets, these take arguments not bound
+// to placeholders separately so interpreters can receive them as argv
+func (c *Command) runsCode() bool {
+	return c.Code.valid() && c.Code.Language != "sh"
+}
+
+// template to run the command with arguments only known at run time
+//
+// The first `arity` arguments are referenced as `$__1`...`$__n` and any
+// remaining arguments as `$@` so scripts can shift them off first. Code
+// snippets reference them as double quoted words for the shell to expand
+// outside the quoted code, `$*` holding the rest, and return whether the
+// rest are passed to the interpreter as argv instead.
+func (c *Command) template() (string, int, bool, error) {
+	cmd := c.baseCommand()
+	names := paramPositions(c.Params)
+	arity := placeholderArity(cmd, names)
+
+	args := []string{}
+	for i := 1; i <= arity; i++ {
+		args = append(args, fmt.Sprintf("\"$__%d\"", i))
+	}
+
+	if c.runsCode() {
+		_, all := resolvePlaceholders(findPlaceholders(cmd), names)
+		s, _, err := bindPlaceholderArgs(cmd, append(args, "\"$*\""), names)
+		if err != nil {
+			return "", 0, false, err
+		}
+		return s, arity, !all, nil
 	}
 
-	return strings.TrimSpace(fmt.Sprintf("%s %s", cmd, strings.Join(subs, " ")))
+	s, err := bindPlaceholders(cmd, append(args, "\"$@\""), names)
+	if err != nil {
+		return "", 0, false, err
+	}
+	return s, arity, false, nil
+}
+
+// Runnable returns true if there is a command to run at this scope
+func (c *Command) Runnable() bool {
+	return len(c.Code.ScriptPath()) > 0 || len(strings.TrimSpace(c.baseCommand())) > 0
+}
+
+// baseCommand before any arguments are applied
+func (c *Command) baseCommand() string {
+	if c.Mode == ExclusiveMode { // Only run this command
+		return c.Name
+	}
+	return c.expand()
 }
 
 func (c *Command) expand() string {
@@ -236,16 +373,72 @@ func (c *Command) expand() string {
 	return strings.Join(reversed(cmds), " ")
 }
 
-func (c *Command) substitute(arg string) string {
+// environment for this command with variables inherited from parent scopes,
+// closer scopes override variables with the same name
+func (c *Command) environment() map[string]string {
+	env := map[string]string{}
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		for k, v := range cmd.Env {
+			if _, ok := env[k]; !ok {
+				env[k] = v
+			}
+		}
+	})
+	return env
+}
+
+// directory to run this command from, using the closest scope that sets one
+func (c *Command) directory() string {
+	var dir string
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		if len(cmd.Dir) > 0 {
+			dir = cmd.Dir
+			*stop = true
+		}
+	})
+	return dir
+}
+
+// runs directly if this command or a parent opted in
+func (c *Command) runs() bool {
+	run := false
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		if cmd.Run {
+			run = true
+			*stop = true
+		}
+	})
+	return run
+}
+
+// substitutions in scope for this command keyed by alias, closer scopes
+// take precedence
+func (c *Command) substitutions() map[string]string {
+	subs := map[string]string{}
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		for alias, sub := range cmd.Subs {
+			if _, ok := subs[alias]; !ok {
+				subs[alias] = sub.Name
+			}
+		}
+	})
+	return subs
+}
+
+// substitution for arg and the closest scope defining it, or arg and nil if
+// no scope substitutes it
+func (c *Command) substitution(arg string) (string, *Command) {
 	sub := arg
+	var scope *Command
 	c.reverseWalk(func(cmd *Command, stop *bool) {
 		s := cmd.Subs[arg]
 		if s != nil {
 			sub = s.Name
+			scope = cmd
 			*stop = true
 		}
 	})
-	return sub
+	return sub, scope
 }
 
 func (c *Command) reverseWalk(fn func(*Command, *bool)) {
@@ -292,12 +485,114 @@ func (c *Command) link(parent *Command) {
 	if c.Code == nil {
 		c.Code = &Code{}
 	}
+	if c.Commands == nil {
+		c.Commands = map[string]*Command{}
+	}
+	if c.Subs == nil {
+		c.Subs = map[string]*Substitution{}
+	}
 	for _, cmd := range c.Commands {
 		cmd.link(c)
 	}
 }
 
-func (c *Command) build(keyPath, command, description string, code *Code, aliasOnly bool, mode string) {
+// merge an overlay command from another manifest layer into this one,
+// fields set on the overlay take precedence and children are merged
+//
+// An overlay that redefines the command replaces how it runs entirely, its
+// params, environment and directory aren't inherited so leaving them empty
+// clears them. Otherwise they're merged with the existing ones.
+func (c *Command) merge(overlay *Command) {
+	if overlay == nil {
+		return
+	}
+
+	if len(overlay.Name) > 0 || overlay.Code.valid() {
+		c.Name = overlay.Name
+		c.Code = overlay.Code
+		c.Mode = overlay.Mode
+		c.AliasOnly = overlay.AliasOnly
+		c.Run = overlay.Run
+		c.Params = overlay.Params
+		c.Env = nil
+		c.Dir = overlay.Dir
+		c.source = overlay.source
+	}
+	if len(overlay.Description) > 0 {
+		c.Description = overlay.Description
+	}
+	if len(overlay.Params) > 0 {
+		c.Params = overlay.Params
+	}
+	if len(overlay.Dir) > 0 {
+		c.Dir = overlay.Dir
+	}
+	for alias, sub := range overlay.Subs {
+		c.Subs[alias] = sub
+	}
+	for name, value := range overlay.Env {
+		c.addEnv(name, value)
+	}
+
+	for alias, cmd := range overlay.Commands {
+		if existing := c.Commands[alias]; existing != nil {
+			existing.merge(cmd)
+		} else {
+			c.addCommand(cmd)
+			cmd.relink()
+		}
+	}
+}
+
+// clone command tree with copies of all sub commands, substitutions, code,
+// params and environment
+func (c *Command) clone() *Command {
+	cmd := *c
+	cmd.parent = nil
+
+	cmd.Commands = map[string]*Command{}
+	for alias, sub := range c.Commands {
+		cmd.Commands[alias] = sub.clone()
+	}
+
+	cmd.Subs = map[string]*Substitution{}
+	for alias, sub := range c.Subs {
+		s := *sub
+		cmd.Subs[alias] = &s
+	}
+
+	if c.Code != nil {
+		code := *c.Code
+		cmd.Code = &code
+	}
+
+	cmd.Params = nil
+	for _, p := range c.Params {
+		param := *p
+		param.Values = append([]string(nil), p.Values...)
+		cmd.Params = append(cmd.Params, &param)
+	}
+
+	if c.Env != nil {
+		cmd.Env = map[string]string{}
+		for k, v := range c.Env {
+			cmd.Env[k] = v
+		}
+	}
+
+	return &cmd
+}
+
+// relink key paths for all sub commands after moving this command
+func (c *Command) relink() {
+	for _, cmd := range c.Commands {
+		cmd.parent = c
+		cmd.KeyPath = fmt.Sprintf("%s.%s", c.KeyPath, cmd.Alias)
+		cmd.relink()
+	}
+}
+
+func (c *Command) build(keyPath, command, description string, code *Code, aliasOnly bool, mode, dir string) {
 	if len(keyPath) == 0 {
 		return
 	}
@@ -328,6 +623,84 @@ func (c *Command) build(keyPath, command, description string, code *Code, aliasO
 	cmd.Code = code
 	cmd.AliasOnly = aliasOnly
 	cmd.Mode = ModeFromString(mode)
+	cmd.Dir = dir
+}
+
+// addEnv variable at this scope
+func (c *Command) addEnv(name, value string) {
+	if c.Env == nil {
+		c.Env = map[string]string{}
+	}
+	c.Env[name] = value
+}
+
+// removeEnv variable at this scope
+func (c *Command) removeEnv(name string) {
+	delete(c.Env, name)
+	if len(c.Env) == 0 {
+		c.Env = nil
+	}
+}
+
+// addParam at this scope replacing any existing param with the same name
+func (c *Command) addParam(param *Param) {
+	if param == nil {
+		return
+	}
+
+	for i, p := range c.Params {
+		if p.Name == param.Name {
+			c.Params[i] = param
+			return
+		}
+	}
+	c.Params = append(c.Params, param)
+}
+
+// removeParam at this scope
+func (c *Command) removeParam(name string) {
+	for i, p := range c.Params {
+		if p.Name == name {
+			c.Params = append(c.Params[:i], c.Params[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *Command) usage() string {
+	usage := []string{c.Alias}
+	for _, p := range c.Params {
+		usage = append(usage, p.Usage())
+	}
+	return strings.Join(usage, " ")
+}
+
+func (c *Command) longDescription() string {
+	if len(c.Params) == 0 {
+		return c.Description
+	}
+
+	lines := []string{}
+	if len(c.Description) > 0 {
+		lines = append(lines, c.Description, "")
+	}
+	lines = append(lines, "Parameters:")
+	for _, p := range c.Params {
+		line := fmt.Sprintf("  %s (%s)", p.Name, ParamTypeFromString(p.Type))
+		if len(p.Description) > 0 {
+			line += " " + p.Description
+		}
+		if ParamTypeFromString(p.Type) == EnumParam {
+			line += fmt.Sprintf(" [%s]", strings.Join(p.Values, ", "))
+		}
+		if len(p.Default) > 0 {
+			line += fmt.Sprintf(" (default: %s)", p.Default)
+		} else if !p.Required() {
+			line += " (optional)"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (c *Command) commandList() []string {
@@ -351,6 +724,23 @@ func reversed(strs []string) []string {
 	return r
 }
 
+func joinedParams(params []*Param) string {
+	names := []string{}
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func joinedEnv(env map[string]string) string {
+	names := []string{}
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
 func joinedSubs(subMap map[string]*Substitution) string {
 	subs := []string{}
 	for sub := range subMap {
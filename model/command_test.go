@@ -21,9 +21,9 @@ func TestNewCommand(t *testing.T) {
 		code        *Code
 		expected    *Command
 	}{
-		{"empty alias", "cmd", "", false, "", nil, &Command{nil, "cmd", "cmd", "cmd", false, "", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode}},
-		{"empty name", "", "alias", false, "", nil, &Command{nil, "alias", "", "alias", false, "", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode}},
-		{"valid alias", "cmd", "cmd-alias", false, "description", nil, &Command{nil, "cmd-alias", "cmd", "cmd-alias", false, "description", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode}},
+		{"empty alias", "cmd", "", false, "", nil, &Command{nil, "", "cmd", "cmd", "cmd", false, "", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode, nil, nil, "", false}},
+		{"empty name", "", "alias", false, "", nil, &Command{nil, "", "alias", "", "alias", false, "", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode, nil, nil, "", false}},
+		{"valid alias", "cmd", "cmd-alias", false, "description", nil, &Command{nil, "", "cmd-alias", "cmd", "cmd-alias", false, "description", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode, nil, nil, "", false}},
 	}
 
 	for _, test := range tests {
@@ -174,20 +174,83 @@ func TestExecutionString(t *testing.T) {
 		name     string
 		command  *Command
 		args     []string
+		expErr   bool
 		expected string
 	}{
-		{"one level nil args", fakeCommand(1), nil, "one"},
-		{"one level empty args", fakeCommand(1), []string{}, "one"},
-		{"one level no dot arg", fakeCommand(1), []string{"arg"}, "one arg"},
-		{"one level dot arg", fakeCommand(1), []string{"arg.1"}, "one arg.1"},
-		{"n level no dot args", fakeCommand(3).Commands["two-alias"].Commands["three-alias"], []string{"arg1", "arg2"}, "one two three arg1 arg2"},
-		{"n level dot args", fakeCommand(4).Commands["two-alias"], []string{"arg.1", "arg2", "arg.3"}, "one two arg.1 arg2 arg.3"},
-		{"n level dot sub args", fakeCommand(4).Commands["two-alias"], []string{"arg.1", "one-sub", "two-sub"}, "one two arg.1 one two"},
+		{"one level nil args", fakeCommand(1), nil, false, "one"},
+		{"one level empty args", fakeCommand(1), []string{}, false, "one"},
+		{"one level no dot arg", fakeCommand(1), []string{"arg"}, false, "one arg"},
+		{"one level dot arg", fakeCommand(1), []string{"arg.1"}, false, "one arg.1"},
+		{"n level no dot args", fakeCommand(3).Commands["two-alias"].Commands["three-alias"], []string{"arg1", "arg2"}, false, "one two three arg1 arg2"},
+		{"n level dot args", fakeCommand(4).Commands["two-alias"], []string{"arg.1", "arg2", "arg.3"}, false, "one two arg.1 arg2 arg.3"},
+		{"n level dot sub args", fakeCommand(4).Commands["two-alias"], []string{"arg.1", "one-sub", "two-sub"}, false, "one two arg.1 one two"},
+		{"placeholder args", fakeCommandWithName(1, "kubectl logs ${1} -n ${2} --tail=100"), []string{"pod", "ns"}, false, "kubectl logs pod -n ns --tail=100"},
+		{"placeholder sub args", fakeCommandWithName(1, "echo ${1}"), []string{"one-sub"}, false, "echo one"},
+		{"placeholder missing args", fakeCommandWithName(1, "echo ${1} ${2}"), []string{"arg1"}, true, ""},
+		{"param args", fakeCommandWithParams(1, "echo ${count} ${env}"), []string{"3"}, false, "echo 3 dev"},
+		{"param invalid args", fakeCommandWithParams(1, "echo ${count}"), []string{"three"}, true, ""},
+		{"param missing args", fakeCommandWithParams(1, "echo"), nil, true, ""},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			if actual := test.command.executionString(test.args); test.expected != actual {
+			actual, err := test.command.executionString(test.args)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if test.expected != actual {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  *Command
+		env      map[string]map[string]string
+		expected map[string]string
+	}{
+		{"no env", fakeCommand(2).Commands["two-alias"], nil, map[string]string{}},
+		{"inherited env", fakeCommand(2).Commands["two-alias"], map[string]map[string]string{"one-alias": {"FOO": "foo"}}, map[string]string{"FOO": "foo"}},
+		{"merged env", fakeCommand(2).Commands["two-alias"], map[string]map[string]string{"one-alias": {"FOO": "foo"}, "two-alias": {"BAR": "bar"}}, map[string]string{"FOO": "foo", "BAR": "bar"}},
+		{"overridden env", fakeCommand(2).Commands["two-alias"], map[string]map[string]string{"one-alias": {"FOO": "foo"}, "two-alias": {"FOO": "bar"}}, map[string]string{"FOO": "bar"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.command.reverseWalk(func(cmd *Command, stop *bool) {
+				for k, v := range test.env[cmd.Alias] {
+					cmd.addEnv(k, v)
+				}
+			})
+			if actual := test.command.environment(); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDirectory(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  *Command
+		dirs     map[string]string
+		expected string
+	}{
+		{"no dir", fakeCommand(2).Commands["two-alias"], nil, ""},
+		{"inherited dir", fakeCommand(2).Commands["two-alias"], map[string]string{"one-alias": "/foo"}, "/foo"},
+		{"overridden dir", fakeCommand(2).Commands["two-alias"], map[string]string{"one-alias": "/foo", "two-alias": "/bar"}, "/bar"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.command.reverseWalk(func(cmd *Command, stop *bool) {
+				cmd.Dir = test.dirs[cmd.Alias]
+			})
+			if actual := test.command.directory(); actual != test.expected {
 				t.Errorf("expected: %s, actual: %s", test.expected, actual)
 			}
 		})
@@ -300,7 +363,7 @@ func TestBuild(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			test.command.build(test.keyPath, test.commandStr, "", &Code{}, test.aliasOnly, ConcatenateMode.String())
+			test.command.build(test.keyPath, test.commandStr, "", &Code{}, test.aliasOnly, ConcatenateMode.String(), "")
 			if !reflect.DeepEqual(test.expected, test.command) {
 				t.Errorf("expected: %s, actual: %s", test.expected, test.command)
 			}
@@ -332,7 +395,7 @@ func TestKeys(t *testing.T) {
 		command  *Command
 		expected []string
 	}{
-		{"keys", fakeCommand(1), []string{"keypath", "alias", "command", "description", "commands", "substitutions", "code", "mode", "aliasOnly"}},
+		{"keys", fakeCommand(1), []string{"keypath", "alias", "command", "description", "commands", "substitutions", "code", "mode", "aliasOnly", "params", "env", "dir", "source"}},
 	}
 
 	for _, test := range tests {
@@ -363,6 +426,10 @@ func TestFields(t *testing.T) {
 				"keypath":       "one-alias",
 				"mode":          "concatenate",
 				"aliasOnly":     false,
+				"params":        "",
+				"env":           "",
+				"dir":           "",
+				"source":        "",
 			},
 		},
 	}
@@ -448,6 +515,19 @@ func fakeCommandWithPrefix(depth int, prefix string) *Command {
 	return firstCmd
 }
 
+func fakeCommandWithName(depth int, name string) *Command {
+	cmd := fakeCommand(depth)
+	cmd.Name = name
+	return cmd
+}
+
+func fakeCommandWithParams(depth int, name string) *Command {
+	cmd := fakeCommandWithName(depth, name)
+	cmd.addParam(&Param{Name: "count", Type: "int"})
+	cmd.addParam(&Param{Name: "env", Type: "enum", Default: "dev", Values: []string{"dev", "prod"}})
+	return cmd
+}
+
 func fakeBuiltCommand(startDepth, endDepth int, keyPath, command string) *Command {
 	first := fakeCommand(int(math.Max(float64(startDepth), float64(endDepth))))
 	cmd := first
@@ -476,7 +556,7 @@ func TestCommandEffectiveCommand(t *testing.T) {
 		fields fields
 		want   string
 	}{
-		{"use code", fields{"", &Code{"js", "code"}, ConcatenateMode}, "code"},
+		{"use code", fields{"", &Code{Language: "js", Snippet: "code"}, ConcatenateMode}, "code"},
 		{"concatenate", fields{"command", nil, ConcatenateMode}, "command"},
 		{"independent", fields{"command", nil, IndependentMode}, "command;"},
 		{"exclusive", fields{"command", nil, ExclusiveMode}, "command;"},
@@ -5,11 +5,33 @@ type Config struct {
 	Verbose     bool `json:"verbose"`
 	AliasesOnly bool `json:"aliasesOnly"`
 	Mode        Mode `json:"mode"`
+
+	// BackupDir for startup file backups, defaults to ~/.nostromo/backups
+	BackupDir string `json:"backupDir,omitempty" yaml:"backupdir,omitempty"`
+
+	// BackupCount kept per startup file, defaults to 10
+	BackupCount int `json:"backupCount,omitempty" yaml:"backupcount,omitempty"`
+
+	// Languages for code snippets in addition to the built in ones
+	Languages []*Language `json:"languages,omitempty" yaml:",omitempty"`
+}
+
+// Language declares an interpreter to run code snippets with
+//
+// Snippets are passed inline after the flag, or as a script file that is the
+// last argument to the interpreter in file mode. Languages with the same name
+// as a built in language replace it.
+type Language struct {
+	Name        string `json:"name"`
+	Interpreter string `json:"interpreter"`
+	Flag        string `json:"flag,omitempty" yaml:",omitempty"`
+	File        bool   `json:"file,omitempty" yaml:",omitempty"`
+	Extension   string `json:"extension,omitempty" yaml:",omitempty"`
 }
 
 // Keys as ordered list of fields for logging
 func (c *Config) Keys() []string {
-	return []string{"verbose", "aliasesOnly", "mode"}
+	return []string{"verbose", "aliasesOnly", "mode", "backupDir", "backupCount"}
 }
 
 // Fields interface for logging
@@ -18,5 +40,7 @@ func (c *Config) Fields() map[string]interface{} {
 		"verbose":     c.Verbose,
 		"aliasesOnly": c.AliasesOnly,
 		"mode":        c.Mode.String(),
+		"backupDir":   c.BackupDir,
+		"backupCount": c.BackupCount,
 	}
 }
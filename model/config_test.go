@@ -11,7 +11,7 @@ func TestConfigKeys(t *testing.T) {
 		manifest *Manifest
 		expected []string
 	}{
-		{"keys", fakeManifest(1, 1), []string{"verbose", "aliasesOnly", "mode"}},
+		{"keys", fakeManifest(1, 1), []string{"verbose", "aliasesOnly", "mode", "backupDir", "backupCount"}},
 	}
 
 	for _, test := range tests {
@@ -36,6 +36,8 @@ func TestConfigFields(t *testing.T) {
 				"verbose":     true,
 				"aliasesOnly": false,
 				"mode":        "concatenate",
+				"backupDir":   "",
+				"backupCount": 0,
 			},
 		},
 	}
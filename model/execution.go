@@ -0,0 +1,41 @@
+package model
+
+// Execution holds the resolved details needed to run a command
+type Execution struct {
+	KeyPath  string            `json:"keyPath"`
+	Language string            `json:"language,omitempty"`
+	Command  string            `json:"command"`
+	Env      map[string]string `json:"env,omitempty"`
+	Dir      string            `json:"dir,omitempty"`
+
+	// Script file run by the interpreter for the language
+	Script string `json:"script,omitempty"`
+
+	// Args not bound to placeholders in code snippets, interpreters decide
+	// whether to append them to the code or pass them as argv. Scripts get
+	// all arguments as argv.
+	Args []string `json:"args,omitempty"`
+
+	// Run directly instead of evaluating in the shell
+	Run bool `json:"run,omitempty"`
+}
+
+// Template holds the details needed to run a command from a script where
+// arguments are only known at run time
+//
+// The command references the first `Arity` arguments as `$__1`...`$__n`
+// and the rest as `$@`. Scripts are expected to apply substitutions and
+// param defaults, assign the leading arguments and shift them off first.
+//
+// Code snippets reference arguments as double quoted words, the rest as
+// `"$*"`, for scripts to expand outside the quoted code.
+type Template struct {
+	Execution
+	Arity int
+
+	// Argv is true if the rest of the arguments are passed to the code
+	// snippet as argv
+	Argv   bool
+	Params []*Param
+	Subs   map[string]string
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Steps explaining a command in the order they're resolved
+const (
+	KeyPathStep       = "key path"
+	ArgumentsStep     = "arguments"
+	SubstitutionsStep = "substitutions"
+	ParamsStep        = "params"
+	ScopesStep        = "scopes"
+	CommandStep       = "command"
+	EvalStep          = "eval"
+)
+
+// Explanation of how arguments resolve to the command to run
+type Explanation struct {
+	Args          []string                 `json:"args"`
+	KeyPath       string                   `json:"keyPath"`
+	Source        string                   `json:"source,omitempty"`
+	Remaining     []string                 `json:"remaining"`
+	Substitutions []*ExplainedSubstitution `json:"substitutions"`
+	Params        []*ExplainedParam        `json:"params"`
+	Values        []string                 `json:"values"`
+	Scopes        []*ExplainedScope        `json:"scopes"`
+	Command       string                   `json:"command"`
+	Execution     *Execution               `json:"execution"`
+
+	// Eval string for the shell, set by callers since it depends on the shell
+	Eval string `json:"eval,omitempty"`
+
+	// Failed step and its error, steps after it aren't resolved
+	Failed string `json:"failed,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Fail the explanation at step returning err
+func (x *Explanation) Fail(step string, err error) error {
+	x.Failed, x.Error = step, err.Error()
+	return err
+}
+
+// ExplainedSubstitution of an argument by the scope defining it
+type ExplainedSubstitution struct {
+	Arg     string `json:"arg"`
+	Value   string `json:"value"`
+	KeyPath string `json:"keyPath"`
+}
+
+// ExplainedParam bound to an argument or its default
+type ExplainedParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+
+	// Default is true if no argument was given for the param
+	Default bool `json:"default"`
+}
+
+func (p *ExplainedParam) String() string {
+	if p.Default {
+		return fmt.Sprintf("%s = %s (default)", p.Name, p.Value)
+	}
+	return fmt.Sprintf("%s = %s", p.Name, p.Value)
+}
+
+// ExplainedScope along the key path and what it contributes to the command
+type ExplainedScope struct {
+	KeyPath string `json:"keyPath"`
+	Mode    string `json:"mode"`
+	Command string `json:"command"`
+
+	// Ignored scopes don't contribute since the command is exclusive or
+	// runs a script
+	Ignored bool `json:"ignored"`
+}
+
+func (s *ExplainedScope) String() string {
+	switch {
+	case s.Ignored:
+		return fmt.Sprintf("%s (%s): ignored", s.KeyPath, s.Mode)
+	case len(s.Command) == 0:
+		return fmt.Sprintf("%s (%s): nothing", s.KeyPath, s.Mode)
+	}
+	return fmt.Sprintf("%s (%s): %s", s.KeyPath, s.Mode, s.Command)
+}
+
+// Explain how args resolve to the command to run without running it
+//
+// Steps are resolved in order, if one fails the explanation has the steps
+// before it along with the failure.
+func (m *Manifest) Explain(args []string) (*Explanation, error) {
+	x := &Explanation{
+		Args:          args,
+		Remaining:     []string{},
+		Substitutions: []*ExplainedSubstitution{},
+		Params:        []*ExplainedParam{},
+		Values:        []string{},
+		Scopes:        []*ExplainedScope{},
+	}
+
+	c, remaining := m.resolve(args)
+	if c == nil {
+		return x, x.Fail(KeyPathStep, fmt.Errorf("unable to find command '%s'", strings.Join(args, " ")))
+	}
+	x.KeyPath, x.Source, x.Remaining = c.KeyPath, c.source, remaining
+
+	values, subs, err := c.resolveArgs(remaining)
+	x.Substitutions, x.Values = subs, values
+	for i, p := range c.Params {
+		if i >= len(values) {
+			break
+		}
+		x.Params = append(x.Params, &ExplainedParam{p.Name, values[i], i >= len(remaining)})
+	}
+	if err != nil {
+		return x, x.Fail(ParamsStep, err)
+	}
+
+	// Scopes from the root down to the command
+	x.Command = c.baseCommand()
+	script := len(c.Code.ScriptPath()) > 0
+	if script {
+		x.Command = c.Code.ScriptPath()
+	}
+	var scopes []*Command
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		scopes = append(scopes, cmd)
+	})
+	ignoreParents := c.Mode == ExclusiveMode || script
+	for i := len(scopes) - 1; i >= 0; i-- {
+		cmd := scopes[i]
+		s := &ExplainedScope{KeyPath: cmd.KeyPath, Mode: cmd.Mode.String(), Command: cmd.effectiveCommand()}
+		switch {
+		case cmd == c && ignoreParents:
+			s.Command = x.Command
+		case ignoreParents:
+			s.Ignored = true
+		}
+		x.Scopes = append(x.Scopes, s)
+	}
+
+	e, err := m.Execution(args)
+	if err != nil {
+		return x, x.Fail(CommandStep, err)
+	}
+	x.Execution = e
+
+	return x, nil
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifestExplain(t *testing.T) {
+	m := NewManifest()
+	m.AddCommand("build", "cd src", "", &Code{}, false, "independent", "")
+	m.AddCommand("build.ios", "make ios ${config}", "", &Code{}, false, "concatenate", "")
+	m.AddCommand("build.android", "gradle build", "", &Code{}, false, "exclusive", "")
+	m.AddParam("build.ios", &Param{Name: "config", Type: "string", Default: "debug"})
+	m.AddSubstitution("build", "release", "rel")
+
+	tests := []struct {
+		name       string
+		args       []string
+		expErr     bool
+		expKeyPath string
+		remaining  []string
+		subs       []*ExplainedSubstitution
+		params     []string
+		values     []string
+		scopes     []string
+		command    string
+	}{
+		{"missing", []string{"missing"}, true, "", nil, nil, nil, nil, nil, ""},
+		{
+			"concatenate",
+			[]string{"build", "ios", "rel", "x"},
+			false,
+			"build.ios",
+			[]string{"rel", "x"},
+			[]*ExplainedSubstitution{{"rel", "release", "build"}},
+			[]string{"config = release"},
+			[]string{"release", "x"},
+			[]string{"build (independent): cd src;", "build.ios (concatenate): make ios ${config}"},
+			"cd src; make ios release x",
+		},
+		{
+			"dotted key path",
+			[]string{"build.ios", "rel"},
+			false,
+			"build.ios",
+			[]string{"rel"},
+			[]*ExplainedSubstitution{{"rel", "release", "build"}},
+			[]string{"config = release"},
+			[]string{"release"},
+			[]string{"build (independent): cd src;", "build.ios (concatenate): make ios ${config}"},
+			"cd src; make ios release",
+		},
+		{
+			"exclusive",
+			[]string{"build", "android"},
+			false,
+			"build.android",
+			[]string{},
+			[]*ExplainedSubstitution{},
+			nil,
+			[]string{},
+			[]string{"build (independent): ignored", "build.android (exclusive): gradle build"},
+			"gradle build",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			x, err := m.Explain(test.args)
+			if test.expErr {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			if x.KeyPath != test.expKeyPath {
+				t.Errorf("expected key path: %s, actual: %s", test.expKeyPath, x.KeyPath)
+			}
+			if !reflect.DeepEqual(x.Remaining, test.remaining) {
+				t.Errorf("expected remaining: %v, actual: %v", test.remaining, x.Remaining)
+			}
+			if !reflect.DeepEqual(x.Substitutions, test.subs) {
+				t.Errorf("expected substitutions: %v, actual: %v", test.subs, x.Substitutions)
+			}
+			var params []string
+			for _, p := range x.Params {
+				params = append(params, p.String())
+			}
+			if !reflect.DeepEqual(params, test.params) {
+				t.Errorf("expected params: %v, actual: %v", test.params, params)
+			}
+			if !reflect.DeepEqual(x.Values, test.values) {
+				t.Errorf("expected values: %v, actual: %v", test.values, x.Values)
+			}
+			var scopes []string
+			for _, s := range x.Scopes {
+				scopes = append(scopes, s.String())
+			}
+			if !reflect.DeepEqual(scopes, test.scopes) {
+				t.Errorf("expected scopes: %v, actual: %v", test.scopes, scopes)
+			}
+			if x.Execution.Command != test.command {
+				t.Errorf("expected command: %s, actual: %s", test.command, x.Execution.Command)
+			}
+		})
+	}
+}
+
+func TestManifestExplainFailed(t *testing.T) {
+	m := NewManifest()
+	m.AddCommand("deploy", "deploy ${env} ${region}", "", &Code{}, false, "concatenate", "")
+	m.AddParam("deploy", &Param{Name: "env", Type: "string"})
+	m.AddParam("deploy", &Param{Name: "region", Type: "string", Default: "us"})
+	m.AddParam("deploy", &Param{Name: "count", Type: "int", Default: "1"})
+
+	tests := []struct {
+		name    string
+		args    []string
+		failed  string
+		keyPath string
+		params  []string
+	}{
+		{"missing command", []string{"missing"}, KeyPathStep, "", nil},
+		{"missing param", []string{"deploy"}, ParamsStep, "deploy", nil},
+		{"invalid param", []string{"deploy", "prod", "eu", "x"}, ParamsStep, "deploy", []string{"env = prod", "region = eu"}},
+		{"valid", []string{"deploy", "prod"}, "", "deploy", []string{"env = prod", "region = us (default)", "count = 1 (default)"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			x, err := m.Explain(test.args)
+			if len(test.failed) > 0 && err == nil {
+				t.Errorf("expected error but got none")
+			} else if len(test.failed) == 0 && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			}
+
+			if x.Failed != test.failed {
+				t.Errorf("expected failed step: %q, actual: %q", test.failed, x.Failed)
+			}
+			if err != nil && x.Error != err.Error() {
+				t.Errorf("expected error: %s, actual: %s", err, x.Error)
+			}
+			if x.KeyPath != test.keyPath {
+				t.Errorf("expected key path: %s, actual: %s", test.keyPath, x.KeyPath)
+			}
+			var params []string
+			for _, p := range x.Params {
+				params = append(params, p.String())
+			}
+			if !reflect.DeepEqual(params, test.params) {
+				t.Errorf("expected params: %v, actual: %v", test.params, params)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/pokanop/nostromo/keypath"
@@ -11,9 +13,37 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Policies for resolving conflicts when importing commands
+const (
+	// MergePolicy merges imported commands into existing ones
+	MergePolicy = "merge"
+
+	// OverwritePolicy replaces existing commands with imported ones
+	OverwritePolicy = "overwrite"
+
+	// SkipPolicy keeps existing commands and ignores imported ones
+	SkipPolicy = "skip"
+)
+
+var supportedImportPolicies = []string{MergePolicy, OverwritePolicy, SkipPolicy}
+
+// SchemaVersion of manifests written by this version of nostromo
+//
+// Bump this whenever the manifest format changes in a way older versions
+// can't read without losing data, and register a migration in `config` to
+// upgrade manifests from the previous version.
+const SchemaVersion = 5
+
 // Manifest is the main container for nostromo based commands
 type Manifest struct {
-	Version  string              `json:"version"`
+	// Version of nostromo that last wrote the manifest
+	Version string `json:"version"`
+
+	// Schema version describing the manifest format
+	Schema int `json:"schema" yaml:"schema"`
+
 	Config   *Config             `json:"config"`
 	Commands map[string]*Command `json:"commands"`
 }
@@ -22,6 +52,7 @@ type Manifest struct {
 func NewManifest() *Manifest {
 	return &Manifest{
 		Version:  "1.0",
+		Schema:   SchemaVersion,
 		Config:   &Config{},
 		Commands: map[string]*Command{},
 	}
@@ -32,13 +63,214 @@ func NewManifest() *Manifest {
 // This must be run after parsing a manifest to walk the command
 // tree and build links.
 func (m *Manifest) Link() {
+	if m.Config == nil {
+		m.Config = &Config{}
+	}
+	if m.Commands == nil {
+		m.Commands = map[string]*Command{}
+	}
 	for _, cmd := range m.Commands {
 		cmd.link(nil)
 	}
 }
 
+// SetSource of the manifest layer for all commands
+func (m *Manifest) SetSource(source string) {
+	for _, cmd := range m.Commands {
+		cmd.forwardWalk(func(c *Command, stop *bool) {
+			c.source = source
+		})
+	}
+}
+
+// SetDir the manifest was loaded from to resolve relative script paths
+func (m *Manifest) SetDir(dir string) {
+	for _, cmd := range m.Commands {
+		cmd.forwardWalk(func(c *Command, stop *bool) {
+			if c.Code != nil {
+				c.Code.baseDir = dir
+			}
+		})
+	}
+}
+
+// Merge commands from an overlay manifest layered on top of this one
+//
+// Commands from the overlay take precedence and sub commands are merged
+// along the key path. The overlay should not be used after merging.
+func (m *Manifest) Merge(overlay *Manifest) {
+	if overlay == nil {
+		return
+	}
+
+	for alias, cmd := range overlay.Commands {
+		if existing := m.Commands[alias]; existing != nil {
+			existing.merge(cmd)
+		} else {
+			m.Commands[alias] = cmd
+		}
+	}
+}
+
+// Conflicts returns key paths of commands that already exist when importing
+// another manifest under the namespace key path, including ones nested
+// anywhere in the imported command trees
+func (m *Manifest) Conflicts(other *Manifest, namespace string) []string {
+	var conflicts []string
+	if other == nil {
+		return conflicts
+	}
+
+	for _, cmd := range other.Commands {
+		cmd.Walk(func(c *Command, stop *bool) {
+			keyPath := c.KeyPath
+			if len(namespace) > 0 {
+				keyPath = keypath.KeyPath([]string{namespace, keyPath})
+			}
+			if m.Find(keyPath) != nil {
+				conflicts = append(conflicts, keyPath)
+			}
+		})
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+// Changes returns key paths of commands added, removed or changed in the
+// edited manifest, commands only count as changed if their own fields
+// differ and not their sub commands
+func (m *Manifest) Changes(edited *Manifest) (added, removed, changed []string) {
+	before := m.commandsByKeyPath()
+	after := edited.commandsByKeyPath()
+
+	for keyPath, c := range after {
+		if b, ok := before[keyPath]; !ok {
+			added = append(added, keyPath)
+		} else if b.signature() != c.signature() {
+			changed = append(changed, keyPath)
+		}
+	}
+	for keyPath := range before {
+		if _, ok := after[keyPath]; !ok {
+			removed = append(removed, keyPath)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return
+}
+
+func (m *Manifest) commandsByKeyPath() map[string]*Command {
+	cmds := map[string]*Command{}
+	if m == nil {
+		return cmds
+	}
+	for _, cmd := range m.Commands {
+		cmd.Walk(func(c *Command, stop *bool) {
+			cmds[c.KeyPath] = c
+		})
+	}
+	return cmds
+}
+
+// Import commands from another manifest under the namespace key path
+//
+// An empty namespace imports commands at the root. Conflicting commands are
+// resolved using the policy which must be one of `SupportedImportPolicies`.
+func (m *Manifest) Import(other *Manifest, namespace, policy string) error {
+	if other == nil {
+		return fmt.Errorf("manifest to import is nil")
+	}
+
+	if !IsImportPolicySupported(policy) {
+		return fmt.Errorf("invalid policy, supported policies: %s", supportedImportPolicies)
+	}
+
+	var parent *Command
+	if len(namespace) > 0 {
+		parent = m.ensure(namespace)
+		if parent == nil {
+			return fmt.Errorf("invalid namespace")
+		}
+	}
+
+	for alias, cmd := range other.Commands {
+		commands := m.Commands
+		if parent != nil {
+			commands = parent.Commands
+		}
+
+		existing := commands[alias]
+		if existing != nil {
+			switch policy {
+			case SkipPolicy:
+				continue
+			case MergePolicy:
+				existing.merge(cmd)
+				continue
+			}
+		}
+
+		if parent != nil {
+			parent.addCommand(cmd)
+		} else {
+			cmd.parent = nil
+			cmd.KeyPath = cmd.Alias
+			m.Commands[alias] = cmd
+		}
+		cmd.relink()
+	}
+
+	return nil
+}
+
+// IsImportPolicySupported returns true if policy is supported and false otherwise.
+func IsImportPolicySupported(policy string) bool {
+	for _, p := range supportedImportPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportedImportPolicies returns a list of supported import policies.
+func SupportedImportPolicies() []string {
+	return supportedImportPolicies
+}
+
+// ensure commands exist up to key path without modifying existing ones
+func (m *Manifest) ensure(keyPath string) *Command {
+	keys := keypath.Keys(keyPath)
+	if len(keys[0]) == 0 {
+		return nil
+	}
+
+	cmd := m.Commands[keys[0]]
+	if cmd == nil {
+		cmd = newCommand("", keys[0], "", nil, false, m.Config.Mode.String())
+		m.Commands[cmd.Alias] = cmd
+	}
+
+	for _, key := range keys[1:] {
+		if len(key) == 0 {
+			return nil
+		}
+		next := cmd.Commands[key]
+		if next == nil {
+			next = newCommand("", key, "", nil, false, m.Config.Mode.String())
+			cmd.addCommand(next)
+		}
+		cmd = next
+	}
+
+	return cmd
+}
+
 // AddCommand tree up to key path
-func (m *Manifest) AddCommand(keyPath, command, description string, code *Code, aliasOnly bool, mode string) (bool, error) {
+func (m *Manifest) AddCommand(keyPath, command, description string, code *Code, aliasOnly bool, mode, dir string) (bool, error) {
 	if len(keyPath) == 0 {
 		return false, fmt.Errorf("invalid key path")
 	}
@@ -51,6 +283,7 @@ func (m *Manifest) AddCommand(keyPath, command, description string, code *Code,
 	// Only need to create one command for alias only mode
 	if m.Config.AliasesOnly || aliasOnly {
 		cmd := newCommand(command, keyPath, description, code, true, mode)
+		cmd.Dir = dir
 		m.Commands[cmd.Alias] = cmd
 		return true, nil
 	}
@@ -67,7 +300,7 @@ func (m *Manifest) AddCommand(keyPath, command, description string, code *Code,
 	}
 
 	// Modify or build the rest of the key path of commands
-	cmd.build(keyPath, command, description, code, aliasOnly, mode)
+	cmd.build(keyPath, command, description, code, aliasOnly, mode, dir)
 
 	return isRoot, nil
 }
@@ -93,6 +326,156 @@ func (m *Manifest) RemoveCommand(keyPath string) (bool, error) {
 	return isRoot, nil
 }
 
+// CommandUpdate of fields on an existing command, nil fields are left
+// unchanged
+type CommandUpdate struct {
+	Name        *string
+	Description *string
+	Mode        *string
+	Snippet     *string
+	Script      *string
+	Language    *string
+	Dir         *string
+	Run         *bool
+}
+
+// UpdateCommand at key path patching only the fields set on update
+func (m *Manifest) UpdateCommand(keyPath string, update *CommandUpdate) error {
+	cmd := m.Find(keyPath)
+	if cmd == nil {
+		return fmt.Errorf("command not found")
+	}
+
+	if update.Mode != nil && !IsModeSupported(*update.Mode) {
+		return fmt.Errorf("invalid mode, supported modes: %s", SupportedModes())
+	}
+
+	if update.Name != nil {
+		cmd.Name = *update.Name
+	}
+	if update.Description != nil {
+		cmd.Description = *update.Description
+	}
+	if update.Mode != nil {
+		cmd.Mode = ModeFromString(*update.Mode)
+	}
+	if update.Snippet != nil || update.Script != nil || update.Language != nil {
+		if cmd.Code == nil {
+			cmd.Code = &Code{}
+		}
+		// Commands run either a snippet or a script
+		if update.Snippet != nil {
+			cmd.Code.Snippet = *update.Snippet
+			cmd.Code.Script = ""
+		}
+		if update.Script != nil {
+			cmd.Code.Script = *update.Script
+			cmd.Code.Snippet = ""
+		}
+		if update.Language != nil {
+			cmd.Code.Language = *update.Language
+		}
+	}
+	if update.Dir != nil {
+		cmd.Dir = *update.Dir
+	}
+	if update.Run != nil {
+		cmd.Run = *update.Run
+	}
+
+	return nil
+}
+
+// MoveCommand tree from one key path to another
+//
+// Missing parents of the destination are created and key paths of all sub
+// commands are rewritten. Returns true if root commands changed.
+func (m *Manifest) MoveCommand(from, to string) (bool, error) {
+	cmd, err := m.relocatable(from, to)
+	if err != nil {
+		return false, err
+	}
+
+	isRoot := len(keypath.Keys(from)) == 1 || m.Commands[keypath.Keys(to)[0]] == nil
+
+	if _, err := m.RemoveCommand(from); err != nil {
+		return false, err
+	}
+
+	if err := m.place(cmd, to); err != nil {
+		return false, err
+	}
+
+	return isRoot, nil
+}
+
+// CopyCommand tree from one key path to another
+//
+// The copy includes all sub commands, substitutions and code. Returns true
+// if a root command was created.
+func (m *Manifest) CopyCommand(from, to string) (bool, error) {
+	cmd, err := m.relocatable(from, to)
+	if err != nil {
+		return false, err
+	}
+
+	isRoot := m.Commands[keypath.Keys(to)[0]] == nil
+
+	if err := m.place(cmd.clone(), to); err != nil {
+		return false, err
+	}
+
+	return isRoot, nil
+}
+
+// relocatable command at key path from that can be moved or copied to the
+// key path to
+func (m *Manifest) relocatable(from, to string) (*Command, error) {
+	cmd := m.Find(from)
+	if cmd == nil {
+		return nil, fmt.Errorf("command not found")
+	}
+
+	for _, key := range keypath.Keys(to) {
+		if len(key) == 0 {
+			return nil, fmt.Errorf("invalid key path")
+		}
+	}
+
+	if m.Find(to) != nil {
+		return nil, fmt.Errorf("command already exists: %s", to)
+	}
+
+	if strings.HasPrefix(to, from+keypath.Delimiter) {
+		return nil, fmt.Errorf("cannot move %s into itself", from)
+	}
+
+	return cmd, nil
+}
+
+// place a detached command at key path creating missing parents
+func (m *Manifest) place(cmd *Command, keyPath string) error {
+	keys := keypath.Keys(keyPath)
+	cmd.Alias = keys[len(keys)-1]
+
+	if len(keys) == 1 {
+		cmd.parent = nil
+		cmd.KeyPath = cmd.Alias
+		m.Commands[cmd.Alias] = cmd
+		cmd.relink()
+		return nil
+	}
+
+	parent := m.ensure(keypath.KeyPath(keys[:len(keys)-1]))
+	if parent == nil {
+		return fmt.Errorf("invalid key path")
+	}
+	parent.addCommand(cmd)
+	cmd.relink()
+
+	return nil
+}
+
 // AddSubstitution with name and alias at key path
 func (m *Manifest) AddSubstitution(keyPath, name, alias string) error {
 	cmd := m.Find(keyPath)
@@ -119,6 +502,76 @@ func (m *Manifest) RemoveSubstitution(keyPath, alias string) error {
 	return nil
 }
 
+// AddParam with name and type at key path
+func (m *Manifest) AddParam(keyPath string, param *Param) error {
+	cmd := m.Find(keyPath)
+	if cmd == nil {
+		return fmt.Errorf("command not found")
+	}
+
+	if param == nil || len(param.Name) == 0 {
+		return fmt.Errorf("invalid param name")
+	}
+
+	if !IsParamTypeSupported(param.Type) {
+		return fmt.Errorf("invalid param type, supported types: %s", SupportedParamTypes())
+	}
+
+	if ParamTypeFromString(param.Type) == EnumParam && len(param.Values) == 0 {
+		return fmt.Errorf("enum params must provide values")
+	}
+
+	if len(param.Default) > 0 {
+		if _, err := param.validate(param.Default); err != nil {
+			return err
+		}
+	}
+
+	cmd.addParam(param)
+
+	return nil
+}
+
+// RemoveParam at key path for given name
+func (m *Manifest) RemoveParam(keyPath, name string) error {
+	cmd := m.Find(keyPath)
+	if cmd == nil {
+		return fmt.Errorf("command not found")
+	}
+
+	cmd.removeParam(name)
+
+	return nil
+}
+
+// AddEnv variable with name and value at key path
+func (m *Manifest) AddEnv(keyPath, name, value string) error {
+	cmd := m.Find(keyPath)
+	if cmd == nil {
+		return fmt.Errorf("command not found")
+	}
+
+	if !envNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name '%s'", name)
+	}
+
+	cmd.addEnv(name, value)
+
+	return nil
+}
+
+// RemoveEnv variable at key path for given name
+func (m *Manifest) RemoveEnv(keyPath, name string) error {
+	cmd := m.Find(keyPath)
+	if cmd == nil {
+		return fmt.Errorf("command not found")
+	}
+
+	cmd.removeEnv(name)
+
+	return nil
+}
+
 // Find command at key path or nil if missing
 func (m *Manifest) Find(keyPath string) *Command {
 	for _, cmd := range m.Commands {
@@ -149,24 +602,112 @@ func (m *Manifest) AsYAML() string {
 
 // ExecutionString from input if possible or return error
 func (m *Manifest) ExecutionString(args []string) (string, string, error) {
+	e, err := m.Execution(args)
+	if err != nil {
+		return "", "", err
+	}
+	return e.Language, strings.TrimSpace(e.Command + " " + strings.Join(e.Args, " ")), nil
+}
+
+// Execution resolves input to the command to run or returns an error
+func (m *Manifest) Execution(args []string) (*Execution, error) {
+	c, remaining := m.resolve(args)
+	if c == nil {
+		log.Debug("arguments:", args)
+		return nil, fmt.Errorf("unable to execute command '%s'", strings.Join(args, " "))
+	}
+
+	log.Debug("key path:", c.KeyPath)
+	if len(remaining) > 0 {
+		log.Debug("arguments:", remaining)
+	}
+
+	if script := c.Code.ScriptPath(); len(script) > 0 {
+		values, err := c.executionValues(remaining)
+		if err != nil {
+			return nil, err
+		}
+		return &Execution{
+			KeyPath:  c.KeyPath,
+			Language: c.Code.Language,
+			Command:  script,
+			Script:   script,
+			Args:     values,
+			Env:      c.environment(),
+			Dir:      c.directory(),
+			Run:      c.runs(),
+		}, nil
+	}
+
+	var execStr string
+	var codeArgs []string
+	var err error
+	if c.runsCode() {
+		execStr, codeArgs, err = c.executionArgs(remaining)
+	} else {
+		execStr, err = c.executionString(remaining)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Execution{
+		KeyPath:  c.KeyPath,
+		Language: c.Code.Language,
+		Command:  execStr,
+		Args:     codeArgs,
+		Env:      c.environment(),
+		Dir:      c.directory(),
+		Run:      c.runs(),
+	}, nil
+}
+
+// resolve args to the command at the shortest matching key path and the
+// arguments after it, or nil if there's no such command
+//
+// Arguments can contain the key path delimiter, e.g., `foo.bar`, so they're
+// consumed whole until the keys in the key path are used up.
+func (m *Manifest) resolve(args []string) (*Command, []string) {
 	for _, cmd := range m.Commands {
 		keyPath := cmd.shortestKeyPath(keypath.KeyPath(args))
-		if len(keyPath) > 0 {
-			count := len(keypath.Keys(keyPath))
-
-			log.Debug("key path:", keyPath)
-			if len(args[count:]) > 0 {
-				log.Debug("arguments:", args[count:])
-			}
+		if len(keyPath) == 0 {
+			continue
+		}
 
-			c := cmd.find(keyPath)
-			return c.Code.Language, c.executionString(args[count:]), nil
+		i := 0
+		for n := len(keypath.Keys(keyPath)); n > 0 && i < len(args); i++ {
+			n -= len(keypath.Keys(args[i]))
 		}
+		return cmd.find(keyPath), args[i:]
+	}
+	return nil, nil
+}
+
+// Template for the command at key path to be run from a script
+func (m *Manifest) Template(keyPath string) (*Template, error) {
+	c := m.Find(keyPath)
+	if c == nil {
+		return nil, fmt.Errorf("command not found")
 	}
 
-	log.Debug("arguments:", args)
+	cmd, arity, argv, err := c.template()
+	if err != nil {
+		return nil, err
+	}
 
-	return "", "", fmt.Errorf("unable to execute command '%s'", strings.Join(args, " "))
+	return &Template{
+		Execution: Execution{
+			KeyPath:  c.KeyPath,
+			Language: c.Code.Language,
+			Command:  cmd,
+			Script:   c.Code.ScriptPath(),
+			Env:      c.environment(),
+			Dir:      c.directory(),
+		},
+		Arity:  arity,
+		Argv:   argv,
+		Params: c.Params,
+		Subs:   c.substitutions(),
+	}, nil
 }
 
 // Keys as ordered list of fields for logging
@@ -36,7 +36,7 @@ func TestManifestAddCommand(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			_, err := test.manifest.AddCommand(test.keyPath, test.command, "", nil, false, test.mode.String())
+			_, err := test.manifest.AddCommand(test.keyPath, test.command, "", nil, false, test.mode.String(), "")
 			if test.expErr && err == nil {
 				t.Errorf("expected error but got none")
 			} else if !test.expErr && err != nil {
@@ -48,6 +48,161 @@ func TestManifestAddCommand(t *testing.T) {
 	}
 }
 
+func TestManifestUpdateCommand(t *testing.T) {
+	name, description, mode, invalid := "updated", "updated description", "exclusive", "invalid"
+	snippet, language := "puts 1", "ruby"
+	script := "scripts/0-one-alias.rb"
+	tests := []struct {
+		name     string
+		keyPath  string
+		update   *CommandUpdate
+		expErr   bool
+		expected *Command
+	}{
+		{"missing command", "missing", &CommandUpdate{Name: &name}, true, nil},
+		{"invalid mode", "0-one-alias", &CommandUpdate{Mode: &invalid}, true, nil},
+		{"no fields", "0-one-alias", &CommandUpdate{}, false, &Command{Name: "0-one", Description: "0-one-description", Mode: ConcatenateMode}},
+		{"name only", "0-one-alias", &CommandUpdate{Name: &name}, false, &Command{Name: name, Description: "0-one-description", Mode: ConcatenateMode}},
+		{"description and mode", "0-one-alias", &CommandUpdate{Description: &description, Mode: &mode}, false, &Command{Name: "0-one", Description: description, Mode: ExclusiveMode}},
+		{"code", "0-one-alias", &CommandUpdate{Snippet: &snippet, Language: &language}, false, &Command{Name: "0-one", Description: "0-one-description", Code: &Code{Language: language, Snippet: snippet}}},
+		{"script", "0-one-alias", &CommandUpdate{Script: &script, Language: &language}, false, &Command{Name: "0-one", Description: "0-one-description", Code: &Code{Language: language, Script: script}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := fakeManifest(1, 1)
+			m.Commands["0-one-alias"].Description = "0-one-description"
+			err := m.UpdateCommand(test.keyPath, test.update)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if test.expected != nil {
+				cmd := m.Find(test.keyPath)
+				if cmd.Name != test.expected.Name || cmd.Description != test.expected.Description || cmd.Mode != test.expected.Mode {
+					t.Errorf("expected %s %s %s but got %s %s %s", test.expected.Name, test.expected.Description, test.expected.Mode, cmd.Name, cmd.Description, cmd.Mode)
+				}
+				if test.expected.Code != nil && !reflect.DeepEqual(cmd.Code, test.expected.Code) {
+					t.Errorf("expected code %v but got %v", test.expected.Code, cmd.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestManifestMoveCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		manifest *Manifest
+		expErr   bool
+		expRoot  bool
+		expected int
+	}{
+		{"missing command", "missing", "other", fakeManifest(1, 2), true, false, 2},
+		{"existing destination", "0-one-alias", "1-one-alias", fakeManifest(2, 2), true, false, 4},
+		{"into itself", "0-one-alias", "0-one-alias.0-two-alias.new", fakeManifest(1, 2), true, false, 2},
+		{"invalid destination", "0-one-alias.0-two-alias", "0-one-alias..new", fakeManifest(1, 2), true, false, 2},
+		{"rename root", "0-one-alias", "renamed", fakeManifest(1, 3), false, true, 3},
+		{"rename sub command", "0-one-alias.0-two-alias", "0-one-alias.renamed", fakeManifest(1, 3), false, false, 3},
+		{"sub command to root", "0-one-alias.0-two-alias", "renamed", fakeManifest(1, 3), false, true, 3},
+		{"root to sub command", "1-one-alias", "0-one-alias.renamed", fakeManifest(2, 2), false, true, 4},
+		{"create parents", "0-one-alias.0-two-alias", "new.parent.renamed", fakeManifest(1, 3), false, true, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			subs := 0
+			if cmd := test.manifest.Find(test.from); cmd != nil {
+				cmd.addSubstitution(&Substitution{"name", "alias"})
+				subs = len(cmd.Subs)
+			}
+
+			isRoot, err := test.manifest.MoveCommand(test.from, test.to)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if isRoot != test.expRoot {
+				t.Errorf("expected root %t but got %t", test.expRoot, isRoot)
+			} else if count := test.manifest.count(); count != test.expected {
+				t.Errorf("expected %d commands but got %d", test.expected, count)
+			} else if !test.expErr {
+				if test.manifest.Find(test.from) != nil {
+					t.Errorf("expected %s to be moved", test.from)
+				}
+				cmd := test.manifest.Find(test.to)
+				if cmd == nil {
+					t.Fatalf("expected command at %s", test.to)
+				}
+				if len(cmd.Subs) != subs {
+					t.Errorf("expected %d substitutions but got %d", subs, len(cmd.Subs))
+				}
+				assertKeyPaths(t, test.manifest)
+			}
+		})
+	}
+}
+
+func TestManifestCopyCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		manifest *Manifest
+		expErr   bool
+		expRoot  bool
+		expected int
+	}{
+		{"missing command", "missing", "other", fakeManifest(1, 2), true, false, 2},
+		{"existing destination", "0-one-alias", "1-one-alias", fakeManifest(2, 2), true, false, 4},
+		{"copy root", "0-one-alias", "copied", fakeManifest(1, 3), false, true, 6},
+		{"copy sub command", "0-one-alias.0-two-alias", "0-one-alias.copied", fakeManifest(1, 3), false, false, 5},
+		{"copy into itself", "0-one-alias", "0-one-alias.copied", fakeManifest(1, 2), true, false, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isRoot, err := test.manifest.CopyCommand(test.from, test.to)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if isRoot != test.expRoot {
+				t.Errorf("expected root %t but got %t", test.expRoot, isRoot)
+			} else if count := test.manifest.count(); count != test.expected {
+				t.Errorf("expected %d commands but got %d", test.expected, count)
+			} else if !test.expErr {
+				from, to := test.manifest.Find(test.from), test.manifest.Find(test.to)
+				if from == nil || to == nil {
+					t.Fatalf("expected commands at %s and %s", test.from, test.to)
+				}
+				to.Name = "changed"
+				if from.Name == to.Name {
+					t.Errorf("expected copy to be independent of original")
+				}
+				assertKeyPaths(t, test.manifest)
+			}
+		})
+	}
+}
+
+func assertKeyPaths(t *testing.T, m *Manifest) {
+	for alias, root := range m.Commands {
+		if root.KeyPath != alias || root.parent != nil {
+			t.Errorf("expected root key path %s but got %s", alias, root.KeyPath)
+		}
+		root.forwardWalk(func(c *Command, stop *bool) {
+			for alias, sub := range c.Commands {
+				if sub.parent != c || sub.KeyPath != keypath.KeyPath([]string{c.KeyPath, alias}) {
+					t.Errorf("expected key path %s.%s but got %s", c.KeyPath, alias, sub.KeyPath)
+				}
+			}
+		})
+	}
+}
+
 func TestManifestRemoveCommand(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -143,6 +298,191 @@ func TestManifestRemoveSubstitution(t *testing.T) {
 	}
 }
 
+func TestManifestAddEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyPath  string
+		envName  string
+		value    string
+		manifest *Manifest
+		expErr   bool
+	}{
+		{"empty inputs", "", "", "", fakeManifest(1, 1), true},
+		{"missing key path", "missing", "FOO", "foo", fakeManifest(1, 1), true},
+		{"invalid name", "0-one-alias", "FOO-BAR", "foo", fakeManifest(1, 1), true},
+		{"valid env", "0-one-alias", "FOO", "foo", fakeManifest(1, 1), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.manifest.AddEnv(test.keyPath, test.envName, test.value)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if !test.expErr {
+				cmd := test.manifest.Find(test.keyPath)
+				if cmd.Env[test.envName] != test.value {
+					t.Errorf("expected env is incorrect")
+				}
+			}
+		})
+	}
+}
+
+func TestManifestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest *Manifest
+		overlay  *Manifest
+		keyPath  string
+		expected string
+		count    int
+	}{
+		{"nil overlay", fakeManifest(1, 2), nil, "0-one-alias.0-two-alias", "0-one 0-two", 2},
+		{"new root", fakeManifest(1, 2), fakeManifest(2, 1), "0-one-alias.0-two-alias", "0-one 0-two", 3},
+		{"new child", fakeManifest(1, 1), fakeManifest(1, 2), "0-one-alias.0-two-alias", "0-one 0-two", 2},
+		{"override command", fakeManifest(1, 2), fakeManifestWithName(1, "local"), "0-one-alias.0-two-alias", "local 0-two", 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.manifest.Merge(test.overlay)
+			if count := test.manifest.count(); count != test.count {
+				t.Errorf("expected %d commands but got %d", test.count, count)
+			}
+			_, actual, err := test.manifest.ExecutionString(keypath.Keys(test.keyPath))
+			if err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestManifestMergeRedefined(t *testing.T) {
+	base := func() *Manifest {
+		m := NewManifest()
+		m.AddCommand("deploy", "./deploy.sh", "", &Code{}, false, "concatenate", "")
+		cmd := m.Find("deploy")
+		cmd.Dir = "~/x"
+		cmd.addEnv("STAGE", "prod")
+		return m
+	}
+
+	redefined := NewManifest()
+	redefined.AddCommand("deploy", "make deploy", "", &Code{}, false, "concatenate", "")
+	augmented := NewManifest()
+	augmented.AddCommand("deploy.dry", "--dry-run", "", &Code{}, false, "concatenate", "")
+
+	tests := []struct {
+		name    string
+		overlay *Manifest
+		expDir  string
+		expEnv  map[string]string
+	}{
+		{"redefined clears", redefined, "", nil},
+		{"augmented inherits", augmented, "~/x", map[string]string{"STAGE": "prod"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := base()
+			m.Merge(test.overlay)
+			cmd := m.Find("deploy")
+			if cmd.Dir != test.expDir {
+				t.Errorf("expected dir: %s, actual: %s", test.expDir, cmd.Dir)
+			}
+			if !reflect.DeepEqual(cmd.Env, test.expEnv) {
+				t.Errorf("expected env: %v, actual: %v", test.expEnv, cmd.Env)
+			}
+		})
+	}
+}
+
+func TestManifestImport(t *testing.T) {
+	nested := NewManifest()
+	nested.AddCommand("0-two-alias.extra", "extra", "", &Code{}, false, "concatenate", "")
+
+	tests := []struct {
+		name         string
+		manifest     *Manifest
+		other        *Manifest
+		namespace    string
+		policy       string
+		expErr       bool
+		expConflicts []string
+		keyPath      string
+		expected     string
+	}{
+		{"nil manifest", fakeManifest(1, 1), nil, "", MergePolicy, true, nil, "", ""},
+		{"invalid policy", fakeManifest(1, 1), fakeManifest(1, 1), "", "invalid", true, []string{"0-one-alias"}, "", ""},
+		{"invalid namespace", fakeManifest(1, 1), fakeManifest(1, 1), "foo..bar", MergePolicy, true, nil, "", ""},
+		{"namespace", fakeManifest(1, 1), fakeManifest(1, 2), "ns.team", SkipPolicy, false, nil, "ns.team.0-one-alias.0-two-alias", "0-one 0-two"},
+		{"existing namespace", fakeManifest(1, 1), fakeManifest(1, 2), "0-one-alias", SkipPolicy, false, nil, "0-one-alias.0-one-alias.0-two-alias", "0-one 0-one 0-two"},
+		{"skip conflicts", fakeManifest(1, 1), fakeManifestWithName(1, "other"), "", SkipPolicy, false, []string{"0-one-alias"}, "0-one-alias", "0-one"},
+		{"overwrite conflicts", fakeManifest(1, 2), fakeManifestWithName(1, "other"), "", OverwritePolicy, false, []string{"0-one-alias"}, "0-one-alias", "other"},
+		{"merge conflicts", fakeManifest(1, 2), fakeManifestWithName(1, "other"), "", MergePolicy, false, []string{"0-one-alias"}, "0-one-alias.0-two-alias", "other 0-two"},
+		{"nested conflicts", fakeManifest(1, 3), fakeManifest(1, 2), "", MergePolicy, false, []string{"0-one-alias", "0-one-alias.0-two-alias"}, "0-one-alias.0-two-alias.0-three-alias", "0-one 0-two 0-three"},
+		{"namespaced nested conflicts", fakeManifest(1, 2), nested, "0-one-alias", MergePolicy, false, []string{"0-one-alias.0-two-alias"}, "0-one-alias.0-two-alias.extra", "0-one 0-two extra"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if conflicts := test.manifest.Conflicts(test.other, test.namespace); !reflect.DeepEqual(conflicts, test.expConflicts) {
+				t.Errorf("expected conflicts: %s, actual: %s", test.expConflicts, conflicts)
+			}
+			err := test.manifest.Import(test.other, test.namespace, test.policy)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if !test.expErr {
+				cmd := test.manifest.Find(test.keyPath)
+				if cmd == nil || cmd.KeyPath != test.keyPath {
+					t.Fatalf("expected command at key path %s", test.keyPath)
+				}
+				if _, actual, _ := test.manifest.ExecutionString(keypath.Keys(test.keyPath)); actual != test.expected {
+					t.Errorf("expected: %s, actual: %s", test.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestManifestChanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		manifest   *Manifest
+		edited     *Manifest
+		expAdded   []string
+		expRemoved []string
+		expChanged []string
+	}{
+		{"no changes", fakeManifest(1, 2), fakeManifest(1, 2), nil, nil, nil},
+		{"added", fakeManifest(1, 1), fakeManifest(1, 2), []string{"0-one-alias.0-two-alias"}, nil, nil},
+		{"removed", fakeManifest(1, 2), fakeManifest(1, 1), nil, []string{"0-one-alias.0-two-alias"}, nil},
+		{"changed", fakeManifest(1, 1), fakeManifestWithName(1, "other"), nil, nil, []string{"0-one-alias"}},
+		{"all removed", fakeManifest(2, 1), NewManifest(), nil, []string{"0-one-alias", "1-one-alias"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			added, removed, changed := test.manifest.Changes(test.edited)
+			if !reflect.DeepEqual(added, test.expAdded) {
+				t.Errorf("expected added: %s, actual: %s", test.expAdded, added)
+			}
+			if !reflect.DeepEqual(removed, test.expRemoved) {
+				t.Errorf("expected removed: %s, actual: %s", test.expRemoved, removed)
+			}
+			if !reflect.DeepEqual(changed, test.expChanged) {
+				t.Errorf("expected changed: %s, actual: %s", test.expChanged, changed)
+			}
+		})
+	}
+}
+
 func TestLink(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -246,6 +586,8 @@ func TestManifestExecutionString(t *testing.T) {
 		{"valid nth key path", fakeManifest(1, 4), keypath.Keys("0-one-alias.0-two-alias.0-three-alias"), false, "0-one 0-two 0-three"},
 		{"valid repeat sub key path", fakeSimilarManifest(3, 4), keypath.Keys("1-one-alias.two-alias.three-alias"), false, "1-one two three"},
 		{"valid single key path args", fakeManifest(1, 1), []string{"0-one-alias", "arg1 arg2"}, false, "0-one arg1 arg2"},
+		{"dotted key path args", fakeManifest(1, 2), []string{"0-one-alias.0-two-alias", "arg1"}, false, "0-one 0-two arg1"},
+		{"missing placeholder args", fakeManifestWithName(1, "echo ${1} ${2}"), []string{"0-one-alias", "arg1"}, true, ""},
 	}
 
 	for _, test := range tests {
@@ -262,6 +604,115 @@ func TestManifestExecutionString(t *testing.T) {
 	}
 }
 
+func TestManifestExecutionCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		language   string
+		snippet    string
+		args       []string
+		expCommand string
+		expArgs    []string
+	}{
+		{"snippet", "ruby", "puts 1", []string{"a", "b"}, "puts 1", []string{"a", "b"}},
+		{"multi-line snippet", "ruby", "x = 1\nputs x\n", []string{"a", "b"}, "x = 1\nputs x", []string{"a", "b"}},
+		{"placeholders", "python", "x = '${1}'\nprint(x)", []string{"a", "b"}, "x = 'a'\nprint(x)", []string{"b"}},
+		{"all placeholder", "python", "print('${@}')", []string{"a", "b"}, "print('a b')", nil},
+		{"sh", "sh", "echo 1", []string{"a"}, "echo 1 a", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewManifest()
+			code := &Code{Language: test.language, Snippet: test.snippet}
+			if _, err := m.AddCommand("foo", "", "", code, false, "", ""); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			e, err := m.Execution(append([]string{"foo"}, test.args...))
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if e.Command != test.expCommand {
+				t.Errorf("expected command: %q, actual: %q", test.expCommand, e.Command)
+			}
+			if !reflect.DeepEqual(e.Args, test.expArgs) {
+				t.Errorf("expected args: %v, actual: %v", test.expArgs, e.Args)
+			}
+		})
+	}
+}
+
+func TestManifestExecutionScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		dir       string
+		args      []string
+		expScript string
+		expArgs   []string
+	}{
+		{"relative", "scripts/foo.py", "/home/n/.nostromo", []string{"a", "b"}, "/home/n/.nostromo/scripts/foo.py", []string{"a", "b"}},
+		{"absolute", "/opt/foo.py", "/home/n/.nostromo", nil, "/opt/foo.py", []string{}},
+		{"no dir", "foo.py", "", []string{"a"}, "foo.py", []string{"a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewManifest()
+			code := &Code{Language: "python", Script: test.script}
+			if _, err := m.AddCommand("foo", "", "", code, false, "", ""); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			m.SetDir(test.dir)
+
+			e, err := m.Execution(append([]string{"foo"}, test.args...))
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if e.Script != test.expScript {
+				t.Errorf("expected script: %s, actual: %s", test.expScript, e.Script)
+			}
+			if !reflect.DeepEqual(e.Args, test.expArgs) {
+				t.Errorf("expected args: %v, actual: %v", test.expArgs, e.Args)
+			}
+		})
+	}
+}
+
+func TestManifestTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		manifest   *Manifest
+		keyPath    string
+		expErr     bool
+		expCommand string
+		expArity   int
+	}{
+		{"missing key path", fakeManifest(1, 1), "missing", true, "", 0},
+		{"no placeholders", fakeManifest(1, 1), "0-one-alias", false, `0-one "$@"`, 0},
+		{"placeholders", fakeManifestWithName(1, "echo ${2} ${1}"), "0-one-alias", false, `echo "$__2" "$__1" "$@"`, 2},
+		{"all placeholder", fakeManifestWithName(1, "echo ${1} ${@}"), "0-one-alias", false, `echo "$__1" "$__1" "$@"`, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := test.manifest.Template(test.keyPath)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if !test.expErr {
+				if actual.Command != test.expCommand {
+					t.Errorf("expected: %s, actual: %s", test.expCommand, actual.Command)
+				}
+				if actual.Arity != test.expArity {
+					t.Errorf("expected arity: %d, actual: %d", test.expArity, actual.Arity)
+				}
+			}
+		})
+	}
+}
+
 func TestManifestKeys(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -316,7 +767,7 @@ func TestManifestData(t *testing.T) {
 		fields fields
 		want   interface{}
 	}{
-		{"data", fields{"1.0", &Config{true, true, ConcatenateMode}, map[string]*Command{"foo": {}}}, "manifest"},
+		{"data", fields{"1.0", &Config{Verbose: true, AliasesOnly: true, Mode: ConcatenateMode}, map[string]*Command{"foo": {}}}, "manifest"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -347,7 +798,7 @@ func TestManifestChildren(t *testing.T) {
 		fields fields
 		want   []tree.Node
 	}{
-		{"children", fields{"1.0", &Config{true, true, ConcatenateMode}, commands}, []tree.Node{commands["foo"], commands["bar"]}},
+		{"children", fields{"1.0", &Config{Verbose: true, AliasesOnly: true, Mode: ConcatenateMode}, commands}, []tree.Node{commands["foo"], commands["bar"]}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -373,6 +824,14 @@ func fakeManifest(n, depth int) *Manifest {
 	return m
 }
 
+func fakeManifestWithName(n int, name string) *Manifest {
+	m := fakeManifest(n, 1)
+	for _, cmd := range m.Commands {
+		cmd.Name = name
+	}
+	return m
+}
+
 func fakeManifestAliasesOnly(n, depth int) *Manifest {
 	m := NewManifest()
 	m.Config.AliasesOnly = true
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pokanop/nostromo/pathutil"
+)
+
+// ParamType represents the type of value a parameter accepts.
+type ParamType int
+
+const (
+	// StringParam accepts any value and is the default.
+	StringParam ParamType = iota
+
+	// IntParam accepts integer values.
+	IntParam
+
+	// BoolParam accepts boolean values like `true`, `false`, `1` or `0`.
+	BoolParam
+
+	// EnumParam accepts only one of the values listed on the parameter.
+	EnumParam
+
+	// PathParam accepts a file system path and expands a leading `~`.
+	PathParam
+)
+
+var supportedParamTypeStrings = []string{StringParam.String(), IntParam.String(), BoolParam.String(), EnumParam.String(), PathParam.String()}
+
+var supportedParamTypes = map[string]ParamType{
+	StringParam.String(): StringParam,
+	IntParam.String():    IntParam,
+	BoolParam.String():   BoolParam,
+	EnumParam.String():   EnumParam,
+	PathParam.String():   PathParam,
+}
+
+func (t ParamType) String() string {
+	switch t {
+	case StringParam:
+		return "string"
+	case IntParam:
+		return "int"
+	case BoolParam:
+		return "bool"
+	case EnumParam:
+		return "enum"
+	case PathParam:
+		return "path"
+	}
+	return "unknown"
+}
+
+// IsParamTypeSupported returns true if param type is supported and false otherwise.
+func IsParamTypeSupported(paramType string) bool {
+	_, ok := supportedParamTypes[paramType]
+	return ok
+}
+
+// ParamTypeFromString converts a string to a ParamType and defaults to StringParam if not mappable.
+func ParamTypeFromString(paramType string) ParamType {
+	t, ok := supportedParamTypes[paramType]
+	if !ok {
+		t = StringParam // default
+	}
+	return t
+}
+
+// SupportedParamTypes returns a list of supported param types as strings.
+func SupportedParamTypes() []string {
+	return supportedParamTypeStrings
+}
+
+// Param is a named and typed argument declared on a command
+//
+// The type is kept as a string so manifests remain easy to edit by hand,
+// use `ParamTypeFromString` to interpret it.
+type Param struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default"`
+	Description string   `json:"description"`
+	Values      []string `json:"values"`
+
+	// Optional params may be omitted even without a default, they're bound
+	// to an empty value
+	Optional bool `json:"optional,omitempty" yaml:",omitempty"`
+}
+
+// Required returns true if the param is neither optional nor has a default
+func (p *Param) Required() bool {
+	return !p.Optional && len(p.Default) == 0
+}
+
+// Usage string for the param, e.g., `<name>` or `[name]`
+func (p *Param) Usage() string {
+	if p.Required() {
+		return fmt.Sprintf("<%s>", p.Name)
+	}
+	return fmt.Sprintf("[%s]", p.Name)
+}
+
+// validate the value for this param and return the value to use
+func (p *Param) validate(value string) (string, error) {
+	switch ParamTypeFromString(p.Type) {
+	case IntParam:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("invalid value '%s' for parameter '%s', expected an int", value, p.Name)
+		}
+	case BoolParam:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value '%s' for parameter '%s', expected a bool", value, p.Name)
+		}
+		return strconv.FormatBool(b), nil
+	case EnumParam:
+		for _, v := range p.Values {
+			if v == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("invalid value '%s' for parameter '%s', must be one of [%s]", value, p.Name, strings.Join(p.Values, ", "))
+	case PathParam:
+		return pathutil.Expand(value), nil
+	}
+	return value, nil
+}
+
+// bindParams validates args against declared params in order, filling in
+// defaults for any that are missing. Extra args are returned as is and
+// values bound before an error are returned with it.
+func bindParams(params []*Param, args []string) ([]string, error) {
+	values := []string{}
+	for i, p := range params {
+		v, err := p.bind(i, args)
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+
+	if len(args) > len(params) {
+		values = append(values, args[len(params):]...)
+	}
+
+	return values, nil
+}
+
+// bind the param at position i to its argument or else its default
+func (p *Param) bind(i int, args []string) (string, error) {
+	if i < len(args) {
+		return p.validate(args[i])
+	} else if p.Required() {
+		return "", fmt.Errorf("missing required parameter '%s'", p.Name)
+	} else if len(p.Default) == 0 {
+		return "", nil
+	}
+	return p.validate(p.Default)
+}
+
+// paramPositions maps param names to argument positions starting at 1
+func paramPositions(params []*Param) map[string]int {
+	positions := map[string]int{}
+	for i, p := range params {
+		positions[p.Name] = i + 1
+	}
+	return positions
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParamTypeString(t *testing.T) {
+	tests := []struct {
+		name string
+		p    ParamType
+		want string
+	}{
+		{"string", StringParam, "string"},
+		{"int", IntParam, "int"},
+		{"bool", BoolParam, "bool"},
+		{"enum", EnumParam, "enum"},
+		{"path", PathParam, "path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.String(); got != tt.want {
+				t.Errorf("String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamTypeFromString(t *testing.T) {
+	tests := []struct {
+		name      string
+		paramType string
+		want      ParamType
+	}{
+		{"int", "int", IntParam},
+		{"enum", "enum", EnumParam},
+		{"empty", "", StringParam},
+		{"not supported", "not supported", StringParam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParamTypeFromString(tt.paramType); got != tt.want {
+				t.Errorf("ParamTypeFromString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamRequired(t *testing.T) {
+	tests := []struct {
+		name  string
+		param *Param
+		want  bool
+	}{
+		{"no default", &Param{Name: "p"}, true},
+		{"default", &Param{Name: "p", Default: "foo"}, false},
+		{"optional", &Param{Name: "p", Optional: true}, false},
+		{"optional default", &Param{Name: "p", Default: "foo", Optional: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.param.Required(); got != tt.want {
+				t.Errorf("Required() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		param    *Param
+		value    string
+		expErr   bool
+		expected string
+	}{
+		{"string", &Param{Name: "p", Type: "string"}, "foo", false, "foo"},
+		{"int", &Param{Name: "p", Type: "int"}, "42", false, "42"},
+		{"int invalid", &Param{Name: "p", Type: "int"}, "foo", true, ""},
+		{"bool", &Param{Name: "p", Type: "bool"}, "1", false, "true"},
+		{"bool invalid", &Param{Name: "p", Type: "bool"}, "foo", true, ""},
+		{"enum", &Param{Name: "p", Type: "enum", Values: []string{"foo", "bar"}}, "bar", false, "bar"},
+		{"enum invalid", &Param{Name: "p", Type: "enum", Values: []string{"foo", "bar"}}, "baz", true, ""},
+		{"path", &Param{Name: "p", Type: "path"}, "/tmp", false, "/tmp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := tt.param.validate(tt.value)
+			if tt.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !tt.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if actual != tt.expected {
+				t.Errorf("expected: %s, actual: %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	params := []*Param{
+		{Name: "count", Type: "int"},
+		{Name: "env", Type: "enum", Default: "dev", Values: []string{"dev", "prod"}},
+	}
+	tests := []struct {
+		name     string
+		params   []*Param
+		args     []string
+		expErr   bool
+		expected []string
+	}{
+		{"no params", nil, []string{"foo"}, false, []string{"foo"}},
+		{"all args", params, []string{"1", "prod"}, false, []string{"1", "prod"}},
+		{"default args", params, []string{"1"}, false, []string{"1", "dev"}},
+		{"extra args", params, []string{"1", "prod", "foo"}, false, []string{"1", "prod", "foo"}},
+		{"missing args", params, nil, true, nil},
+		{"invalid args", params, []string{"1", "staging"}, true, nil},
+		{"optional args", []*Param{{Name: "tag", Type: "enum", Values: []string{"a"}, Optional: true}}, nil, false, []string{""}},
+		{"optional args given", []*Param{{Name: "tag", Type: "enum", Values: []string{"a"}, Optional: true}}, []string{"b"}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := bindParams(tt.params, tt.args)
+			if tt.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !tt.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if !tt.expErr && !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected: %s, actual: %s", tt.expected, actual)
+			}
+		})
+	}
+}
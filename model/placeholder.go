@@ -0,0 +1,163 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderRegexp matches `$1`, `$@`, `${1}`, `${@}` and `${name}` style
+// placeholders. Bare positions are a single digit like they are in shell.
+// Named placeholders must start with a lowercase letter and use braces so
+// that environment variables like `${HOME}` are left for the shell to expand,
+// and a leading `$$` escapes a placeholder so `$$1` becomes a literal `$1`.
+var placeholderRegexp = regexp.MustCompile(`\$?\$(?:\{(?:([0-9]+)|(@)|([a-z][a-zA-Z0-9_]*))\}|([1-9])|(@))`)
+
+// placeholder found in a command string
+type placeholder struct {
+	start    int
+	end      int
+	position int
+	name     string
+	all      bool
+	escaped  bool
+}
+
+// findPlaceholders in command string in order of appearance
+func findPlaceholders(cmd string) []*placeholder {
+	var placeholders []*placeholder
+	for _, m := range placeholderRegexp.FindAllStringSubmatchIndex(cmd, -1) {
+		p := &placeholder{start: m[0], end: m[1]}
+		switch {
+		case strings.HasPrefix(cmd[m[0]:], "$$"):
+			p.escaped = true
+		case m[2] != -1:
+			p.position, _ = strconv.Atoi(cmd[m[2]:m[3]])
+		case m[8] != -1:
+			p.position, _ = strconv.Atoi(cmd[m[8]:m[9]])
+		case m[4] != -1 || m[10] != -1:
+			p.all = true
+		case m[6] != -1:
+			p.name = cmd[m[6]:m[7]]
+		}
+		placeholders = append(placeholders, p)
+	}
+	return placeholders
+}
+
+// resolvePlaceholders assigns positions to named placeholders and returns the
+// highest position referenced and whether all arguments are used
+func resolvePlaceholders(placeholders []*placeholder, names map[string]int) (int, bool) {
+	// Resolve positions for numbered and declared placeholders first
+	positions := map[string]int{}
+	max := 0
+	for name, position := range names {
+		positions[name] = position
+		if position > max {
+			max = position
+		}
+	}
+	all := false
+	for _, p := range placeholders {
+		if p.escaped {
+			continue
+		}
+		if p.all {
+			all = true
+		} else if p.position > max {
+			max = p.position
+		}
+	}
+
+	// Remaining named placeholders take the next available positions
+	for _, p := range placeholders {
+		if p.escaped || len(p.name) == 0 {
+			continue
+		}
+		if _, ok := positions[p.name]; !ok {
+			max++
+			positions[p.name] = max
+		}
+		p.position = positions[p.name]
+	}
+
+	return max, all
+}
+
+// placeholderArity is the number of leading arguments referenced by the
+// command string and declared names
+func placeholderArity(cmd string, names map[string]int) int {
+	max, _ := resolvePlaceholders(findPlaceholders(cmd), names)
+	return max
+}
+
+// bindPlaceholders fills placeholders in the command string from the provided
+// arguments. Commands without placeholders have arguments appended as is.
+//
+// Numbered placeholders refer to arguments by position starting at 1 and `$@`
+// expands to all arguments. Named placeholders use the position in `names` if
+// declared, otherwise they are assigned the positions following the highest
+// known position in order of first appearance. Arguments not referenced by any
+// placeholder are appended unless `$@` is used.
+func bindPlaceholders(cmd string, args []string, names map[string]int) (string, error) {
+	s, rest, err := bindPlaceholderArgs(cmd, args, names)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s", s, strings.Join(rest, " "))), nil
+}
+
+// bindPlaceholderArgs like bindPlaceholders returning arguments that aren't
+// referenced separately instead of appending them
+func bindPlaceholderArgs(cmd string, args []string, names map[string]int) (string, []string, error) {
+	placeholders := findPlaceholders(cmd)
+	escaped := 0
+	for _, p := range placeholders {
+		if p.escaped {
+			escaped++
+		}
+	}
+	if escaped == len(placeholders) {
+		cmd = placeholderRegexp.ReplaceAllStringFunc(cmd, func(s string) string { return s[1:] })
+		return strings.TrimSpace(cmd), args, nil
+	}
+
+	max, all := resolvePlaceholders(placeholders, names)
+
+	var b strings.Builder
+	last := 0
+	for _, p := range placeholders {
+		b.WriteString(cmd[last:p.start])
+		last = p.end
+
+		if p.escaped {
+			b.WriteString(cmd[p.start+1 : p.end])
+			continue
+		}
+		if p.all {
+			b.WriteString(strings.Join(args, " "))
+			continue
+		}
+
+		if p.position < 1 {
+			return "", nil, fmt.Errorf("invalid argument position ${%d}", p.position)
+		}
+		if p.position > len(args) {
+			if len(p.name) > 0 {
+				return "", nil, fmt.Errorf("missing argument '%s' at position %d", p.name, p.position)
+			}
+			return "", nil, fmt.Errorf("missing argument at position %d", p.position)
+		}
+		b.WriteString(args[p.position-1])
+	}
+	b.WriteString(cmd[last:])
+
+	// Pass along any trailing arguments not otherwise referenced
+	var rest []string
+	if !all && len(args) > max {
+		rest = args[max:]
+	}
+
+	return strings.TrimSpace(b.String()), rest, nil
+}
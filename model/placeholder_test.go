@@ -0,0 +1,59 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestBindPlaceholders(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      string
+		args     []string
+		names    map[string]int
+		expErr   bool
+		expected string
+	}{
+		{"no placeholders", "echo", []string{"foo", "bar"}, nil, false, "echo foo bar"},
+		{"no placeholders no args", "echo", nil, nil, false, "echo"},
+		{"positional", "echo ${2} ${1}", []string{"foo", "bar"}, nil, false, "echo bar foo"},
+		{"positional suffix", "echo ${1}bar", []string{"foo"}, nil, false, "echo foobar"},
+		{"positional repeated", "echo ${1} ${1}", []string{"foo"}, nil, false, "echo foo foo"},
+		{"positional trailing args", "echo ${1} baz", []string{"foo", "bar"}, nil, false, "echo foo baz bar"},
+		{"all args", "echo [${@}]", []string{"foo", "bar"}, nil, false, "echo [foo bar]"},
+		{"all args no trailing", "echo ${1} ${@}", []string{"foo", "bar"}, nil, false, "echo foo foo bar"},
+		{"named", "kubectl logs ${pod} -n ${ns}", []string{"foo", "bar"}, nil, false, "kubectl logs foo -n bar"},
+		{"named after positional", "echo ${1} ${name}", []string{"foo", "bar"}, nil, false, "echo foo bar"},
+		{"env vars untouched", "cd ${HOME} $HOME", []string{"foo"}, nil, false, "cd ${HOME} $HOME foo"},
+		{"bare positional", "kubectl logs $1 -n $2", []string{"foo", "bar"}, nil, false, "kubectl logs foo -n bar"},
+		{"bare positional single digit", "echo $12", []string{"foo"}, nil, false, "echo foo2"},
+		{"bare all args", `echo "$@"`, []string{"foo", "bar"}, nil, false, `echo "foo bar"`},
+		{"awk fields escaped", "ps aux | awk '{print $$2}'", nil, nil, false, "ps aux | awk '{print $2}'"},
+		{"awk fields escaped with args", "awk '{print $$1, $NF}'", []string{"file.txt"}, nil, false, "awk '{print $1, $NF}' file.txt"},
+		{"sed groups untouched", `sed -E 's/(a)(b)/\2\1/; s/$/!/'`, []string{"f"}, nil, false, `sed -E 's/(a)(b)/\2\1/; s/$/!/' f`},
+		{"shell args escaped", `for f in "$$@"; do echo "$$1" $# $$; done`, []string{"x"}, nil, false, `for f in "$@"; do echo "$1" $# $$; done x`},
+		{"awk fields with placeholder", "awk '{print $${1}, $$1}' $1", []string{"f"}, nil, false, "awk '{print ${1}, $1}' f"},
+		{"missing bare positional", "echo $2", []string{"foo"}, nil, true, ""},
+		{"escaped only", "echo $${1} $${name}", []string{"foo"}, nil, false, "echo ${1} ${name} foo"},
+		{"escaped with declared names", "echo $${a}", []string{"foo", "bar"}, map[string]int{"a": 1}, false, "echo ${a} foo bar"},
+		{"declared names no placeholders", "echo", []string{"foo", "bar"}, map[string]int{"a": 1}, false, "echo foo bar"},
+		{"missing positional", "echo ${2}", []string{"foo"}, nil, true, ""},
+		{"missing named", "echo ${name}", nil, nil, true, ""},
+		{"declared named", "echo ${b} ${a}", []string{"foo", "bar"}, map[string]int{"a": 1, "b": 2}, false, "echo bar foo"},
+		{"declared named trailing args", "echo ${a}", []string{"foo", "bar", "baz"}, map[string]int{"a": 1, "b": 2}, false, "echo foo baz"},
+		{"declared and undeclared named", "echo ${c} ${a}", []string{"foo", "bar", "baz"}, map[string]int{"a": 1, "b": 2}, false, "echo baz foo"},
+		{"invalid position", "echo ${0}", []string{"foo"}, nil, true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := bindPlaceholders(test.cmd, test.args, test.names)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package pathutil
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 )
@@ -44,3 +45,40 @@ func Expand(path string) string {
 func EnsurePath(path string) error {
 	return os.MkdirAll(Abs(path), 0755)
 }
+
+// WriteFile atomically by writing to a temporary file in the same directory
+// and renaming it over path, readers see either the old or new contents and
+// never a partially written file. Symlinks are followed so the file they
+// point to is replaced rather than the link.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	path = Abs(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm.Perm())
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
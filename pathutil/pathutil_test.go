@@ -1,6 +1,7 @@
 package pathutil
 
 import (
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -94,6 +95,70 @@ func TestEnsurePath(t *testing.T) {
 	}
 }
 
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pathutil_tests")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+	if err := ioutil.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		perm     os.FileMode
+		expected string
+	}{
+		{"new file", filepath.Join(dir, "new"), 0644, filepath.Join(dir, "new")},
+		{"existing file", target, 0600, target},
+		{"symlink", link, 0600, target},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := WriteFile(test.path, []byte(test.name), test.perm); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			b, err := ioutil.ReadFile(test.expected)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if string(b) != test.name {
+				t.Errorf("expected: %s, actual: %s", test.name, b)
+			}
+
+			info, err := os.Lstat(test.expected)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if info.Mode() != test.perm {
+				t.Errorf("expected mode: %s, actual: %s", test.perm, info.Mode())
+			}
+		})
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("expected symlink to be preserved")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("expected temp files to be cleaned up, found %d files", len(files))
+	}
+}
+
 func patchEnv(key, value string) func() {
 	bck := os.Getenv(key)
 	deferFunc := func() {
@@ -0,0 +1,230 @@
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const importedComment = "# imported by nostromo: "
+
+var (
+	aliasNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_:+-]+$`)
+	aliasRegexp     = regexp.MustCompile(`^\s*alias\s+([A-Za-z0-9_:+-]+)=(.+?)\s*$`)
+	functionRegexp  = regexp.MustCompile(`^\s*(?:function\s+([A-Za-z0-9_:+-]+)\s*(?:\(\)\s*)?|([A-Za-z0-9_:+-]+)\s*\(\)\s*)\{\s*([^{}]*?)\s*;?\s*\}\s*;?\s*$`)
+)
+
+// builtins that group prefixes must not shadow since they're not on PATH
+var builtins = map[string]bool{
+	"alias": true, "builtin": true, "cd": true, "command": true, "echo": true,
+	"eval": true, "exec": true, "exit": true, "export": true, "kill": true,
+	"popd": true, "printf": true, "pushd": true, "read": true, "set": true,
+	"source": true, "test": true, "type": true, "unset": true,
+}
+
+// lookPath of executables, replaced in tests
+var lookPath = exec.LookPath
+
+// Alias found in a shell startup file outside the nostromo section
+type Alias struct {
+	Name     string
+	Command  string
+	Path     string
+	Line     int
+	Function bool
+}
+
+// Prefix is the first word of the alias command
+func (a *Alias) Prefix() string {
+	fields := strings.Fields(a.Command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// Keys as ordered list of fields for logging
+func (a *Alias) Keys() []string {
+	return []string{"name", "command", "function", "path"}
+}
+
+// Fields interface for logging
+func (a *Alias) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"name":     a.Name,
+		"command":  a.Command,
+		"function": a.Function,
+		"path":     fmt.Sprintf("%s:%d", a.Path, a.Line),
+	}
+}
+
+// FindAliases in all shell startup files sorted by name
+//
+// Aliases and one line functions are parsed from lines outside the
+// nostromo section. If defined more than once, the last definition in the
+// last file sourced wins, like it would in the shell.
+func FindAliases() []*Alias {
+	found := map[string]*Alias{}
+	for _, f := range initFiles {
+		for _, a := range f.aliases() {
+			found[a.Name] = a
+		}
+	}
+
+	var aliases []*Alias
+	for _, a := range found {
+		aliases = append(aliases, a)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Name < aliases[j].Name
+	})
+	return aliases
+}
+
+// GroupAliases by the first word of their commands
+//
+// Aliases sharing a prefix with at least one other alias are grouped under
+// the name given for the prefix or the prefix itself, the rest are returned
+// under an empty name. Prefixes that are executables or builtins need a
+// name since the group's root command would shadow them, e.g., grouping
+// under `git` would break running git itself.
+func GroupAliases(aliases []*Alias, names map[string]string) map[string][]*Alias {
+	counts := map[string]int{}
+	for _, a := range aliases {
+		counts[a.Prefix()]++
+	}
+
+	groups := map[string][]*Alias{}
+	for _, a := range aliases {
+		prefix := a.Prefix()
+		group, ok := names[prefix]
+		if !ok {
+			group = prefix
+			if IsShadowed(prefix) {
+				group = ""
+			}
+		}
+		if counts[prefix] < 2 || !aliasNameRegexp.MatchString(group) {
+			group = ""
+		}
+		groups[group] = append(groups[group], a)
+	}
+	return groups
+}
+
+// IsShadowed returns true if a root command with the name would shadow an
+// executable on PATH or a shell builtin
+func IsShadowed(name string) bool {
+	if builtins[name] {
+		return true
+	}
+	_, err := lookPath(name)
+	return err == nil
+}
+
+// CommentAliases in the startup files they were found in so they no longer
+// conflict with the nostromo versions
+func CommentAliases(aliases []*Alias) error {
+	lines := map[string]map[int]bool{}
+	for _, a := range aliases {
+		if lines[a.Path] == nil {
+			lines[a.Path] = map[int]bool{}
+		}
+		lines[a.Path][a.Line] = true
+	}
+
+	for _, f := range initFiles {
+		if len(lines[f.path]) == 0 {
+			continue
+		}
+
+		f.updatedContent = commentLines(f.content, lines[f.path])
+		if err := f.commit(); err != nil {
+			return err
+		}
+		f.content = f.updatedContent
+	}
+
+	return nil
+}
+
+// aliases defined in the startup file outside the nostromo section
+func (s *startupFile) aliases() []*Alias {
+	var aliases []*Alias
+	inBlock := false
+	for i, line := range strings.Split(s.content, "\n") {
+		switch strings.TrimSpace(line) {
+		case s.comment(beginBlock):
+			inBlock = true
+			continue
+		case s.comment(endBlock):
+			inBlock = false
+			continue
+		}
+		if inBlock {
+			continue
+		}
+
+		if a := parseAlias(line); a != nil {
+			a.Path = s.path
+			a.Line = i + 1
+			aliases = append(aliases, a)
+		}
+	}
+	return aliases
+}
+
+// parseAlias from a line like `alias x='...'` or `x() { ...; }`
+func parseAlias(line string) *Alias {
+	if m := aliasRegexp.FindStringSubmatch(line); m != nil {
+		cmd, ok := unquote(m[2])
+		if !ok || len(strings.TrimSpace(cmd)) == 0 {
+			return nil
+		}
+		return &Alias{Name: m[1], Command: cmd}
+	}
+
+	if m := functionRegexp.FindStringSubmatch(line); m != nil {
+		cmd := strings.TrimSuffix(strings.TrimSpace(m[3]), ";")
+		if len(cmd) == 0 {
+			return nil
+		}
+		// Function arguments like $1 and $@ are placeholders as is
+		return &Alias{Name: m[1] + m[2], Command: cmd, Function: true}
+	}
+
+	return nil
+}
+
+// unquote an alias value, values that are not a single quoted or unquoted
+// word, e.g., multiple aliases on a line, are not supported
+func unquote(value string) (string, bool) {
+	switch {
+	case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+		inner := value[1 : len(value)-1]
+		if strings.Contains(strings.Replace(inner, `'\''`, "", -1), "'") {
+			return "", false
+		}
+		return strings.Replace(inner, `'\''`, "'", -1), true
+	case len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\""):
+		inner := value[1 : len(value)-1]
+		r := strings.NewReplacer(`\"`, `"`, `\\`, `\`, "\\`", "`")
+		return r.Replace(inner), true
+	case !strings.ContainsAny(value, " \t'\""):
+		return value, true
+	}
+	return "", false
+}
+
+// commentLines at the line numbers provided starting at 1
+func commentLines(content string, lines map[int]bool) string {
+	split := strings.Split(content, "\n")
+	for i, line := range split {
+		if lines[i+1] {
+			split[i] = importedComment + line
+		}
+	}
+	return strings.Join(split, "\n")
+}
@@ -0,0 +1,96 @@
+package shell
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseAlias(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want *Alias
+	}{
+		{"empty line", "", nil},
+		{"export", "export FOO=bar", nil},
+		{"single quoted", "alias gs='git status'", &Alias{Name: "gs", Command: "git status"}},
+		{"double quoted", `alias gc="git commit -m \"wip\""`, &Alias{Name: "gc", Command: `git commit -m "wip"`}},
+		{"escaped single quote", `alias q='echo '\''hi'\'''`, &Alias{Name: "q", Command: "echo 'hi'"}},
+		{"unquoted", "  alias vi=vim", &Alias{Name: "vi", Command: "vim"}},
+		{"multiple aliases", "alias a=b c=d", nil},
+		{"empty alias", "alias e=''", nil},
+		{"function", `gl() { git log --oneline "$@"; }`, &Alias{Name: "gl", Command: `git log --oneline "$@"`, Function: true}},
+		{"function keyword", `function mkcd { mkdir -p "$1" && cd "$1"; }`, &Alias{Name: "mkcd", Command: `mkdir -p "$1" && cd "$1"`, Function: true}},
+		{"multi line function", "gl() {", nil},
+		{"not a function", "foo { bar }", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAlias(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAlias() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartupFileAliases(t *testing.T) {
+	content := "alias gs='git status'\n\n# nostromo [section begin]\nalias foo='bar'\n# nostromo [section end]\nalias ll='ls -la'\n"
+	s := makeStartupFileCommon(".zshrc", content, true)
+
+	var got []string
+	for _, a := range s.aliases() {
+		got = append(got, a.Name)
+	}
+	if want := []string{"gs", "ll"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("aliases() = %v, want %v", got, want)
+	}
+}
+
+func TestGroupAliases(t *testing.T) {
+	defer func(f func(string) (string, error)) { lookPath = f }(lookPath)
+	lookPath = func(name string) (string, error) {
+		if name == "git" {
+			return "/usr/bin/git", nil
+		}
+		return "", fmt.Errorf("%s not found", name)
+	}
+
+	aliases := []*Alias{
+		{Name: "gs", Command: "git status"},
+		{Name: "gc", Command: "git commit"},
+		{Name: "kp", Command: "kube pods"},
+		{Name: "kn", Command: "kube nodes"},
+		{Name: "c1", Command: "cd /tmp"},
+		{Name: "c2", Command: "cd /var"},
+		{Name: "ll", Command: "ls -la"},
+		{Name: "run", Command: "./run.sh fast"},
+		{Name: "run2", Command: "./run.sh slow"},
+	}
+
+	got := map[string][]string{}
+	for prefix, grouped := range GroupAliases(aliases, map[string]string{"cd": "go"}) {
+		for _, a := range grouped {
+			got[prefix] = append(got[prefix], a.Name)
+		}
+		sort.Strings(got[prefix])
+	}
+
+	want := map[string][]string{
+		"kube": {"kn", "kp"},
+		"go":   {"c1", "c2"},
+		"":     {"gc", "gs", "ll", "run", "run2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupAliases() = %v, want %v", got, want)
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	content := "export FOO=bar\nalias gs='git status'\nalias ll='ls -la'"
+	want := "export FOO=bar\n# imported by nostromo: alias gs='git status'\nalias ll='ls -la'"
+	if got := commentLines(content, map[int]bool{2: true}); got != want {
+		t.Errorf("commentLines() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,304 @@
+package shell
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/pathutil"
+	"github.com/pokanop/nostromo/stringutil"
+)
+
+// Defaults for startup file backups taken before each commit
+const (
+	DefaultBackupDir   = "~/.nostromo/backups"
+	DefaultBackupCount = 10
+)
+
+const (
+	backupIDTime     = "20060102-150405.000000000"
+	checksumFilename = ".checksum"
+)
+
+var (
+	backupDir   = DefaultBackupDir
+	backupCount = DefaultBackupCount
+)
+
+// Backup of a startup file
+type Backup struct {
+	ID        string
+	Name      string
+	Path      string
+	Timestamp time.Time
+	Size      int64
+
+	backupPath string
+}
+
+// Keys as ordered list of fields for logging
+func (b *Backup) Keys() []string {
+	return []string{"id", "file", "timestamp", "size"}
+}
+
+// Fields interface for logging
+func (b *Backup) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"id":        b.ID,
+		"file":      b.Name,
+		"timestamp": b.Timestamp.Local().Format(time.RFC1123),
+		"size":      b.Size,
+	}
+}
+
+// SetBackups directory and number of backups kept per startup file, empty
+// values use the defaults
+func SetBackups(dir string, count int) {
+	backupDir = dir
+	if len(backupDir) == 0 {
+		backupDir = DefaultBackupDir
+	}
+	backupCount = count
+	if backupCount <= 0 {
+		backupCount = DefaultBackupCount
+	}
+}
+
+// Backups of all startup files, oldest first
+func Backups() ([]*Backup, error) {
+	home, err := pathutil.HomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []*Backup
+	for _, f := range startupFilenames() {
+		dir := backupDirFor(f.Name)
+		files, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, info := range files {
+			if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+				continue
+			}
+
+			ts, err := time.Parse(backupIDTime, info.Name())
+			if err != nil {
+				continue
+			}
+
+			backups = append(backups, &Backup{
+				ID:         info.Name(),
+				Name:       f.Name,
+				Path:       filepath.Join(home, f.Name),
+				Timestamp:  ts,
+				Size:       info.Size(),
+				backupPath: filepath.Join(dir, info.Name()),
+			})
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID < backups[j].ID
+	})
+
+	return backups, nil
+}
+
+// FindBackup by id
+func FindBackup(id string) (*Backup, error) {
+	backups, err := Backups()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range backups {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("backup not found: %s", id)
+}
+
+// DiffBackup shows changes from the backup to the current startup file
+func DiffBackup(id string) (string, error) {
+	b, err := FindBackup(id)
+	if err != nil {
+		return "", err
+	}
+
+	backup, err := ioutil.ReadFile(b.backupPath)
+	if err != nil {
+		return "", err
+	}
+
+	current, err := ioutil.ReadFile(b.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return stringutil.Diff(b.backupPath, b.Path, string(backup), string(current)), nil
+}
+
+// RestoreBackup by id, the current startup file is backed up first so
+// restoring can be reverted
+func RestoreBackup(id string) (*Backup, error) {
+	b, err := FindBackup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(b.backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := os.FileMode(0644)
+	if current, err := ioutil.ReadFile(b.Path); err == nil {
+		if info, err := os.Stat(b.Path); err == nil {
+			mode = info.Mode()
+		}
+		if err := backupStartupFile(b.Path, string(current), mode); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := pathutil.WriteFile(b.Path, content, mode); err != nil {
+		return nil, err
+	}
+
+	return b, saveChecksum(b.Path, string(content))
+}
+
+// ModifiedStartupFiles that were edited outside nostromo since the last
+// commit
+func ModifiedStartupFiles() []string {
+	var modified []string
+	for _, f := range startupFilenames() {
+		path, _, err := findStartupFile(f.Name)
+		if err != nil {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		if isModified(path, string(content)) {
+			modified = append(modified, path)
+		}
+	}
+	return modified
+}
+
+// backupStartupFile content into the backup directory pruning the oldest
+// backups over the limit, nothing is saved if it matches the latest backup
+func backupStartupFile(path, content string, mode os.FileMode) error {
+	if len(content) == 0 {
+		return nil
+	}
+
+	dir := backupDirFor(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	ids, err := backupIDs(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) > 0 {
+		latest, err := ioutil.ReadFile(filepath.Join(dir, ids[len(ids)-1]))
+		if err == nil && string(latest) == content {
+			return nil
+		}
+	}
+
+	id := time.Now().UTC().Format(backupIDTime)
+	if isModified(path, content) {
+		log.Warningf("%s was edited outside nostromo since the last commit, backed up as %s\n", path, id)
+	}
+
+	if err := pathutil.WriteFile(filepath.Join(dir, id), []byte(content), mode); err != nil {
+		return err
+	}
+	ids = append(ids, id)
+
+	for i := 0; i < len(ids)-backupCount; i++ {
+		if err := os.Remove(filepath.Join(dir, ids[i])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveChecksum of the content nostromo wrote to the startup file
+func saveChecksum(path, content string) error {
+	dir := backupDirFor(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	return pathutil.WriteFile(filepath.Join(dir, checksumFilename), []byte(checksum(content)), 0600)
+}
+
+// isModified if content doesn't match the checksum from the last commit
+func isModified(path, content string) bool {
+	b, err := ioutil.ReadFile(filepath.Join(backupDirFor(path), checksumFilename))
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(b)) != checksum(content)
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// backupDirFor startup file path, backups are grouped by the path relative
+// to the home directory
+func backupDirFor(path string) string {
+	name := filepath.Base(path)
+	if home, err := pathutil.HomeDir(); err == nil {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(home, path)
+		}
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	return filepath.Join(pathutil.Abs(backupDir), strings.Replace(filepath.ToSlash(name), "/", "_", -1))
+}
+
+// backupIDs in dir sorted oldest first
+func backupIDs(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		ids = append(ids, f.Name())
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,127 @@
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackupStartupFile(t *testing.T) {
+	home, cleanup := fakeBackupHome(t)
+	defer cleanup()
+
+	SetBackups(filepath.Join(home, "backups"), 2)
+	defer SetBackups("", 0)
+
+	path := filepath.Join(home, ".bashrc")
+	contents := []string{"one\n", "one\n", "two\n", "three\n"}
+	for _, content := range contents {
+		if err := backupStartupFile(path, content, 0644); err != nil {
+			t.Fatalf("unable to backup: %s", err)
+		}
+	}
+
+	backups, err := Backups()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, actual: %d", len(backups))
+	}
+	for i, expected := range []string{"two\n", "three\n"} {
+		b, _ := ioutil.ReadFile(backups[i].backupPath)
+		if string(b) != expected {
+			t.Errorf("expected: %q, actual: %q", expected, b)
+		}
+		if backups[i].Name != ".bashrc" || backups[i].Path != path {
+			t.Errorf("unexpected backup file: %s", backups[i].Path)
+		}
+	}
+}
+
+func TestIsModified(t *testing.T) {
+	home, cleanup := fakeBackupHome(t)
+	defer cleanup()
+
+	SetBackups(filepath.Join(home, "backups"), 0)
+	defer SetBackups("", 0)
+
+	path := filepath.Join(home, ".zshrc")
+	if isModified(path, "one") {
+		t.Errorf("expected file without checksum to not be modified")
+	}
+
+	if err := saveChecksum(path, "one"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if isModified(path, "one") {
+		t.Errorf("expected matching content to not be modified")
+	}
+	if !isModified(path, "two") {
+		t.Errorf("expected different content to be modified")
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	home, cleanup := fakeBackupHome(t)
+	defer cleanup()
+
+	SetBackups(filepath.Join(home, "backups"), 0)
+	defer SetBackups("", 0)
+
+	path := filepath.Join(home, ".zshrc")
+	if err := backupStartupFile(path, "old\n", 0644); err != nil {
+		t.Fatalf("unable to backup: %s", err)
+	}
+	if err := ioutil.WriteFile(path, []byte("new\n"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	backups, err := Backups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected 1 backup, actual: %d", len(backups))
+	}
+
+	diff, err := DiffBackup(backups[0].ID)
+	if err != nil {
+		t.Fatalf("unable to diff: %s", err)
+	}
+	if !strings.Contains(diff, "-old\n+new\n") {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+
+	if _, err := RestoreBackup("missing"); err == nil {
+		t.Errorf("expected error restoring missing backup")
+	}
+
+	if _, err := RestoreBackup(backups[0].ID); err != nil {
+		t.Fatalf("unable to restore: %s", err)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "old\n" {
+		t.Errorf("expected: old, actual: %s", b)
+	}
+	if isModified(path, "old\n") {
+		t.Errorf("expected restored file to not be modified")
+	}
+
+	backups, _ = Backups()
+	if len(backups) != 2 {
+		t.Errorf("expected current file to be backed up before restoring")
+	}
+}
+
+func fakeBackupHome(t *testing.T) (string, func()) {
+	home, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	bck := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	return home, func() {
+		os.Setenv("HOME", bck)
+		os.RemoveAll(home)
+	}
+}
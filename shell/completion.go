@@ -1,11 +1,8 @@
 package shell
 
 import (
-	"bytes"
 	"github.com/pokanop/nostromo/model"
 	"github.com/spf13/cobra"
-	"io/ioutil"
-	"strings"
 )
 
 // CobraCompleter interface for types that can generate a cobra.Command
@@ -15,39 +12,18 @@ type CobraCompleter interface {
 
 // Completion generates shell completion scripts
 func Completion(cmd *cobra.Command) (string, error) {
-	var buf bytes.Buffer
-	var err error
-	if Which() == Zsh {
-		err = cmd.GenZshCompletion(&buf)
-	} else {
-		err = cmd.GenBashCompletion(&buf)
-	}
-	if err != nil {
-		return "", err
-	}
-
-	b, err := ioutil.ReadAll(&buf)
-	if err != nil {
-		return "", err
-	}
-	s := string(b)
-
-	// This is required due to a bug in cobra and zsh support:
-	// https://github.com/spf13/cobra/pull/887
-	if Which() == Zsh {
-		s = strings.Replace(s, "#", "", 1)
-	}
-
-	return s, nil
+	return TargetFor(Which()).Completion(cmd)
 }
 
 // ManifestCompletion scripts for a manifest
 func ManifestCompletion(m *model.Manifest) ([]string, error) {
+	t := TargetFor(Which())
+
 	var completions []string
-	completions = append(completions, shellWrapperFunc())
-	completions = append(completions, shellAliasFuncs(m))
+	completions = append(completions, t.WrapperFunc())
+	completions = append(completions, t.AliasFuncs(m))
 	for _, cmd := range m.Commands {
-		s, err := CommandCompletion(cmd)
+		s, err := t.CommandCompletion(cmd)
 		if err != nil {
 			return nil, err
 		}
@@ -58,5 +34,5 @@ func ManifestCompletion(m *model.Manifest) ([]string, error) {
 
 // CommandCompletion script for a command
 func CommandCompletion(cmd *model.Command) (string, error) {
-	return Completion(cmd.CobraCommand())
+	return TargetFor(Which()).CommandCompletion(cmd)
 }
@@ -0,0 +1,303 @@
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/model"
+)
+
+// Supported export formats
+const (
+	ShFormat            = "sh"
+	BashFunctionsFormat = "bash-functions"
+	MakefileFormat      = "makefile"
+	JustFormat          = "just"
+)
+
+const exportHeader = "Generated by nostromo, changes will be lost when exported again."
+
+var exportFormats = []string{ShFormat, BashFunctionsFormat, MakefileFormat, JustFormat}
+
+var nonIdentifierRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+var nonRecipeRegexp = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+var codeArgRegexp = regexp.MustCompile(`"\$(?:__[0-9]+|\*)"`)
+
+// ExportFormats that manifests can be exported to
+func ExportFormats() []string {
+	return exportFormats
+}
+
+// IsSupportedExportFormat returns true if supported export format and false otherwise
+func IsSupportedExportFormat(format string) bool {
+	for _, f := range exportFormats {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// Export manifest commands as a standalone file in the given format
+//
+// Every command with something to run is exported with substitutions applied
+// to arguments at run time so it behaves like running it through nostromo.
+func Export(m *model.Manifest, format string) (string, error) {
+	if m == nil {
+		return "", fmt.Errorf("manifest must not be nil")
+	}
+
+	templates, err := exportTemplates(m)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case ShFormat:
+		return exportSh(templates), nil
+	case BashFunctionsFormat:
+		return exportBashFunctions(templates), nil
+	case MakefileFormat:
+		return exportMakefile(templates), nil
+	case JustFormat:
+		return exportJust(templates), nil
+	}
+	return "", fmt.Errorf("invalid format, supported formats: %s", exportFormats)
+}
+
+// exportTemplates for all runnable commands sorted by key path
+func exportTemplates(m *model.Manifest) ([]*model.Template, error) {
+	var keyPaths []string
+	for _, cmd := range m.Commands {
+		cmd.Walk(func(c *model.Command, stop *bool) {
+			if c.Runnable() {
+				keyPaths = append(keyPaths, c.KeyPath)
+			}
+		})
+	}
+	sort.Strings(keyPaths)
+
+	var templates []*model.Template
+	for _, keyPath := range keyPaths {
+		t, err := m.Template(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", keyPath, err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+func exportSh(templates []*model.Template) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# " + exportHeader + "\n")
+
+	for _, t := range templates {
+		b.WriteString("\n" + exportFunc(t, false))
+	}
+
+	b.WriteString("\ncase \"$*\" in\n")
+	for _, t := range byDepth(templates) {
+		b.WriteString(exportDispatch(t, 0))
+	}
+	b.WriteString("  *) echo \"usage: $0 <command> [args]\" >&2; exit 1 ;;\n")
+	b.WriteString("esac\n")
+
+	return b.String()
+}
+
+func exportBashFunctions(templates []*model.Template) string {
+	var b strings.Builder
+	b.WriteString("# " + exportHeader + "\n")
+
+	roots := map[string][]*model.Template{}
+	var aliases []string
+	for _, t := range templates {
+		b.WriteString("\n" + exportFunc(t, true))
+
+		root := keypath.Get(t.KeyPath, 0)
+		if _, ok := roots[root]; !ok {
+			aliases = append(aliases, root)
+		}
+		roots[root] = append(roots[root], t)
+	}
+
+	// Root commands dispatch to the deepest matching key path
+	for _, alias := range aliases {
+		b.WriteString(fmt.Sprintf("\n%s() {\n  case \"%s $*\" in\n", alias, alias))
+		for _, t := range byDepth(roots[alias]) {
+			b.WriteString(exportDispatch(t, 1))
+		}
+		b.WriteString(fmt.Sprintf("    *) echo \"%s: unknown command\" >&2; return 1 ;;\n", alias))
+		b.WriteString("  esac\n}\n")
+	}
+
+	return b.String()
+}
+
+func exportMakefile(templates []*model.Template) string {
+	var b strings.Builder
+	b.WriteString("# " + exportHeader + "\n")
+	b.WriteString("# Pass arguments using ARGS, e.g., make build.ios ARGS=\"release\"\n")
+
+	var targets []string
+	for _, t := range templates {
+		targets = append(targets, t.KeyPath)
+	}
+	b.WriteString(fmt.Sprintf("\n.PHONY: %s\n", strings.Join(targets, " ")))
+
+	for _, t := range templates {
+		lines := append([]string{"set -- $(ARGS)"}, exportStatements(t, false)...)
+		for i := range lines[1:] {
+			lines[i+1] = strings.Replace(lines[i+1], "$", "$$", -1)
+		}
+		b.WriteString(fmt.Sprintf("\n%s:\n\t@%s\n", t.KeyPath, strings.Join(lines, "; \\\n\t")))
+	}
+
+	return b.String()
+}
+
+func exportJust(templates []*model.Template) string {
+	var b strings.Builder
+	b.WriteString("# " + exportHeader + "\n")
+
+	for _, t := range templates {
+		name := nonRecipeRegexp.ReplaceAllString(strings.Replace(t.KeyPath, keypath.Delimiter, "-", -1), "-")
+		b.WriteString(fmt.Sprintf("\n%s *ARGS:\n    #!/bin/sh\n    set -- {{ARGS}}\n", name))
+		for _, line := range exportStatements(t, false) {
+			b.WriteString("    " + strings.Replace(line, "{{", "{{{{", -1) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// exportFunc for a command as a shell function
+func exportFunc(t *model.Template, local bool) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s() {\n", exportFuncName(t)))
+	for _, line := range exportStatements(t, local) {
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// exportDispatch case clause calling the function for a command with the
+// key path shifted off the arguments
+func exportDispatch(t *model.Template, indent int) string {
+	keys := keypath.Keys(t.KeyPath)
+	words := strings.Join(keys, " ")
+	return fmt.Sprintf("%s  %s|%s*) shift %d; %s \"$@\" ;;\n",
+		strings.Repeat("  ", indent),
+		doubleQuote(words),
+		doubleQuote(words+" "),
+		len(keys)-indent,
+		exportFuncName(t))
+}
+
+// exportStatements as single line POSIX shell statements to run a command
+func exportStatements(t *model.Template, local bool) []string {
+	var lines []string
+	decl := ""
+	if local {
+		decl = "local "
+	}
+
+	// Apply substitutions to every argument
+	if len(t.Subs) > 0 {
+		var aliases []string
+		for alias := range t.Subs {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+
+		var cases []string
+		for _, alias := range aliases {
+			cases = append(cases, fmt.Sprintf("%s) __a=%s ;;", singleQuote(alias), singleQuote(t.Subs[alias])))
+		}
+		if local {
+			lines = append(lines, "local __a")
+		}
+		lines = append(lines, fmt.Sprintf("%s__n=$#", decl))
+		lines = append(lines, fmt.Sprintf("while [ \"$__n\" -gt 0 ]; do __a=$1; shift; case \"$__a\" in %s esac; set -- \"$@\" \"$__a\"; __n=$((__n - 1)); done", strings.Join(cases, " ")))
+	}
+
+	// Assign leading arguments applying param defaults
+	for i := 1; i <= t.Arity; i++ {
+		var p *model.Param
+		if i <= len(t.Params) {
+			p = t.Params[i-1]
+		}
+
+		switch {
+		case p != nil && !p.Required():
+			lines = append(lines, fmt.Sprintf("%s__%d=${%d:-%s}", decl, i, i, singleQuote(p.Default)))
+		case p != nil:
+			lines = append(lines, fmt.Sprintf("%s__%d=${%d:?missing required parameter %s}", decl, i, i, p.Name))
+		default:
+			lines = append(lines, fmt.Sprintf("%s__%d=${%d}", decl, i, i))
+		}
+	}
+	if t.Arity > 0 {
+		lines = append(lines, fmt.Sprintf("if [ $# -ge %d ]; then shift %d; else shift $#; fi", t.Arity, t.Arity))
+	}
+
+	var cmd string
+	if len(t.Script) > 0 {
+		cmd = buildScriptCmd(t.Script, t.Language) + ` "$@"`
+	} else if _, ok := interpreters[t.Language]; ok {
+		cmd = buildQuotedEvalCmd(quoteCode(t.Command), t.Language)
+		if t.Argv {
+			cmd += ` "$@"`
+		}
+	} else {
+		cmd = strings.TrimSuffix(strings.TrimSpace(t.Command), ";")
+	}
+	lines = append(lines, buildDirSubshell(t.Dir, buildEnvSubshell(t.Env, cmd)))
+
+	return lines
+}
+
+func exportFuncName(t *model.Template) string {
+	return "__nostromo_" + nonIdentifierRegexp.ReplaceAllString(t.KeyPath, "_")
+}
+
+// byDepth orders templates with the deepest key paths first so the most
+// specific command matches when dispatching
+func byDepth(templates []*model.Template) []*model.Template {
+	sorted := append([]*model.Template{}, templates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(keypath.Keys(sorted[i].KeyPath)) > len(keypath.Keys(sorted[j].KeyPath))
+	})
+	return sorted
+}
+
+// singleQuote a string so it is taken literally by the shell
+// quoteCode single quotes a code snippet leaving the argument words it
+// references unquoted so the shell expands them
+func quoteCode(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range codeArgRegexp.FindAllStringIndex(s, -1) {
+		if m[0] > last {
+			b.WriteString(singleQuote(s[last:m[0]]))
+		}
+		b.WriteString(s[m[0]:m[1]])
+		last = m[1]
+	}
+	if last < len(s) || last == 0 {
+		b.WriteString(singleQuote(s[last:]))
+	}
+	return b.String()
+}
+
+func singleQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,138 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestExport(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest *model.Manifest
+		format   string
+		wantErr  bool
+		contains []string
+	}{
+		{"nil manifest", nil, ShFormat, true, nil},
+		{"invalid format", fakeExportManifest(), "invalid", true, nil},
+		{
+			"sh",
+			fakeExportManifest(),
+			ShFormat,
+			false,
+			[]string{
+				"#!/bin/sh",
+				"__nostromo_build_ios() {",
+				`case "$__a" in 'rel') __a='release' ;; esac`,
+				"__1=${1:-'debug'}",
+				`echo ios "$__1" "$@"`,
+				`"build ios"|"build ios "*) shift 2; __nostromo_build_ios "$@" ;;`,
+				`ruby '/opt/deploy.rb' "$@"`,
+				`python -c 'print("hi '"$__1"'")' "$@"`,
+				`perl -e 'print "'"$__1"' '"$__1"' '"$*"'\n"'`,
+			},
+		},
+		{
+			"bash functions",
+			fakeExportManifest(),
+			BashFunctionsFormat,
+			false,
+			[]string{
+				"local __1=${1:-'debug'}",
+				"build() {",
+				`"build ios"|"build ios "*) shift 1; __nostromo_build_ios "$@" ;;`,
+			},
+		},
+		{
+			"makefile",
+			fakeExportManifest(),
+			MakefileFormat,
+			false,
+			[]string{
+				".PHONY: build build.ios",
+				"build.ios:\n\t@set -- $(ARGS); \\",
+				`echo ios "$$__1" "$$@"`,
+			},
+		},
+		{
+			"just",
+			fakeExportManifest(),
+			JustFormat,
+			false,
+			[]string{
+				"build-ios *ARGS:\n    #!/bin/sh\n    set -- {{ARGS}}",
+				`echo ios "$__1" "$@"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Export(tt.manifest, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Export() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("Export() = %v, want to contain %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func fakeExportManifest() *model.Manifest {
+	m := model.NewManifest()
+	m.AddCommand("build", "echo build", "", &model.Code{}, false, "independent", "")
+	m.AddCommand("build.ios", "echo ios ${config}", "", &model.Code{}, false, "exclusive", "")
+	m.AddParam("build.ios", &model.Param{Name: "config", Type: "string", Default: "debug"})
+	m.AddSubstitution("build.ios", "release", "rel")
+	m.AddCommand("deploy", "", "", &model.Code{Language: "ruby", Script: "/opt/deploy.rb"}, false, "", "")
+	m.AddCommand("greet", "", "", &model.Code{Language: "python", Snippet: `print("hi ${name}")`}, false, "", "")
+	m.AddCommand("echo", "", "", &model.Code{Language: "perl", Snippet: `print "${1} ${@}\n"`}, false, "", "")
+	return m
+}
+
+func TestExportRunsSnippets(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	if _, err := exec.LookPath("perl"); err != nil {
+		t.Skip("perl not found")
+	}
+
+	m := model.NewManifest()
+	m.AddCommand("args", "", "", &model.Code{Language: "perl", Snippet: `print "${1}:" . join(",", @ARGV) . "\n"`}, false, "", "")
+	m.AddCommand("all", "", "", &model.Code{Language: "perl", Snippet: `print "${@}\n"`}, false, "", "")
+
+	script, err := Export(m, ShFormat)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"argv", []string{"args", "it's", "b c", "d"}, "it's:b c,d"},
+		{"all", []string{"all", "a", "b'c"}, "a b'c"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"-c", script, "export"}, test.args...)
+			out, err := exec.Command(sh, args...).Output()
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if actual := strings.TrimSpace(string(out)); actual != test.expected {
+				t.Errorf("expected: %q, actual: %q", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/pathutil"
+	"github.com/spf13/cobra"
+)
+
+const sourceFishCompletion = "env NOSTROMO_SHELL=fish nostromo completion | source"
+
+type fish struct{}
+
+func (fish) Shell() Shell {
+	return Fish
+}
+
+func (fish) Names() []string {
+	return []string{"fish"}
+}
+
+func (fish) StartupFiles() []StartupFilename {
+	return []StartupFilename{
+		{Name: ".config/fish/conf.d/nostromo.fish", Preferred: true, ConfigDir: ".config/fish"},
+	}
+}
+
+func (fish) Comment(line string) string {
+	return "# " + line
+}
+
+func (fish) Source(m *model.Manifest) string {
+	return sourceFishCompletion
+}
+
+func (fish) Completion(cmd *cobra.Command) (string, error) {
+	return cobraCompletion(func(w io.Writer) error {
+		return cmd.GenFishCompletion(w, true)
+	})
+}
+
+// CommandCompletion completes child commands matching the key path typed so
+// far since manifest commands can't complete themselves like cobra expects
+func (fish) CommandCompletion(cmd *model.Command) (string, error) {
+	var lines []string
+	cmd.Walk(func(c *model.Command, stop *bool) {
+		for _, child := range sortedChildren(c) {
+			line := fmt.Sprintf("complete -c %s -n 'test (__nostromo_keypath) = %s' -a %s", cmd.Alias, c.KeyPath, fishSingleQuote(child.Alias))
+			if len(child.Description) > 0 {
+				line += fmt.Sprintf(" -d %s", fishSingleQuote(child.Description))
+			}
+			lines = append(lines, line)
+		}
+	})
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func (fish) WrapperFunc() string {
+	return `function __nostromo_cmd; env NOSTROMO_SHELL=fish nostromo $argv; end
+function nostromo; __nostromo_cmd $argv; and test "$argv[1]" != __complete; and __nostromo_cmd completion | source; end
+function __nostromo_keypath; string join . (commandline -opc); end
+function __nostromo_run; set -l cmd (__nostromo_cmd eval $argv); set -l code $status; switch $code; case 0; eval (string join \n -- $cmd); case 127; command $argv; case '*'; eval (string join \n -- $cmd); return $code; end; end`
+}
+
+func (t fish) AliasFuncs(m *model.Manifest) string {
+	var aliases []string
+	for _, c := range m.Commands {
+		var alias string
+		if IsPlainAlias(c) {
+			cmd := fmt.Sprintf("%s $argv", c.Name)
+			alias = fmt.Sprintf("function %s; %s; end", c.Alias, t.DirBlock(c.Dir, cmd))
+		} else {
+			alias = fmt.Sprintf("function %s; __nostromo_run %s $argv; end", c.Alias, c.Alias)
+		}
+		aliases = append(aliases, alias)
+	}
+	return fmt.Sprintf("\n%s\n", strings.Join(aliases, "\n"))
+}
+
+// EnvBlock exports variables local to a block so they're only seen by the
+// command
+func (fish) EnvBlock(env map[string]string, cmd string) string {
+	if len(env) == 0 {
+		return cmd
+	}
+
+	var vars []string
+	for _, name := range sortedNames(env) {
+		vars = append(vars, fmt.Sprintf("set -lx %s %s; ", name, fishDoubleQuote(env[name])))
+	}
+	return fmt.Sprintf("begin; %s%s; end", strings.Join(vars, ""), cmd)
+}
+
+// DirBlock returns to the previous directory after running the command since
+// fish has no subshells
+func (fish) DirBlock(dir, cmd string) string {
+	if len(dir) == 0 {
+		return cmd
+	}
+
+	return fmt.Sprintf("if pushd %s; %s; popd; end", fishDoubleQuote(pathutil.Expand(dir)), cmd)
+}
+
+// fishDoubleQuote a string escaping characters that are special within
+// double quotes in fish other than `$`
+func fishDoubleQuote(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"")
+	return "\"" + r.Replace(s) + "\""
+}
+
+// fishSingleQuote a string so it is taken literally by fish
+func fishSingleQuote(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "'", "\\'")
+	return "'" + r.Replace(s) + "'"
+}
+
+// sortedChildren of a command by alias
+func sortedChildren(c *model.Command) []*model.Command {
+	var aliases []string
+	for alias := range c.Commands {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var children []*model.Command
+	for _, alias := range aliases {
+		children = append(children, c.Commands[alias])
+	}
+	return children
+}
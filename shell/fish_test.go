@@ -0,0 +1,69 @@
+package shell
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestFishEvalString(t *testing.T) {
+	os.Setenv("NOSTROMO_SHELL", "fish")
+	defer os.Unsetenv("NOSTROMO_SHELL")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		dir  string
+		want string
+	}{
+		{"no env or dir", nil, "", "echo foo"},
+		{"env", map[string]string{"FOO": "foo", "BAR": "$HOME/\"bar\""}, "", "begin; set -lx BAR \"$HOME/\\\"bar\\\"\"; set -lx FOO \"foo\"; echo foo; end"},
+		{"dir with env", map[string]string{"FOO": "foo"}, "/tmp", "if pushd \"/tmp\"; begin; set -lx FOO \"foo\"; echo foo; end; popd; end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvalString(&model.Execution{Command: "echo foo", Env: tt.env, Dir: tt.dir}, false)
+			if err != nil {
+				t.Errorf("EvalString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalString() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFishAliasFuncs(t *testing.T) {
+	m := model.NewManifest()
+	m.AddCommand("foo", "echo foo", "", &model.Code{}, false, "concatenate", "")
+	m.AddCommand("bar", "ls -la", "", &model.Code{}, true, "concatenate", "")
+
+	got := fish{}.AliasFuncs(m)
+	for _, want := range []string{
+		"function foo; __nostromo_run foo $argv; end",
+		"function bar; ls -la $argv; end",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("AliasFuncs() = %v, want to contain %v", got, want)
+		}
+	}
+}
+
+func TestFishCommandCompletion(t *testing.T) {
+	m := model.NewManifest()
+	m.AddCommand("build.ios", "xcodebuild", "Build ios app", &model.Code{}, false, "concatenate", "")
+	m.AddCommand("build.android", "gradle", "", &model.Code{}, false, "concatenate", "")
+
+	want := `complete -c build -n 'test (__nostromo_keypath) = build' -a 'android'
+complete -c build -n 'test (__nostromo_keypath) = build' -a 'ios' -d 'Build ios app'
+`
+	got, err := fish{}.CommandCompletion(m.Find("build"))
+	if err != nil {
+		t.Errorf("CommandCompletion() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("CommandCompletion() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package shell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+	"github.com/spf13/cobra"
+)
+
+// nushell can't evaluate generated code at startup so the whole integration
+// is written to the startup file whenever the manifest changes. Commands are
+// run with `sh` since they are written in POSIX shell syntax.
+type nushell struct {
+	posix
+}
+
+func (nushell) Shell() Shell {
+	return Nushell
+}
+
+func (nushell) Names() []string {
+	return []string{"nu"}
+}
+
+func (nushell) StartupFiles() []StartupFilename {
+	return []StartupFilename{
+		{Name: ".config/nushell/config.nu", Preferred: true},
+	}
+}
+
+func (t nushell) Source(m *model.Manifest) string {
+	lines := []string{nuCobraCompleter("nostromo"), t.WrapperFunc()}
+	if m != nil {
+		lines = append(lines, strings.TrimSpace(t.AliasFuncs(m)))
+		for _, cmd := range sortedCommands(m) {
+			if cmd.AliasOnly {
+				continue
+			}
+			s, _ := t.CommandCompletion(cmd)
+			lines = append(lines, strings.TrimSpace(s))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (nushell) Completion(cmd *cobra.Command) (string, error) {
+	return nuCobraCompleter(cmd.Name()), nil
+}
+
+// CommandCompletion completes child commands matching the key path typed so far
+func (nushell) CommandCompletion(cmd *model.Command) (string, error) {
+	var rows []string
+	cmd.Walk(func(c *model.Command, stop *bool) {
+		for _, child := range sortedChildren(c) {
+			rows = append(rows, fmt.Sprintf("[%s %s %s]", nuDoubleQuote(c.KeyPath), nuDoubleQuote(child.Alias), nuDoubleQuote(child.Description)))
+		}
+	})
+
+	table := "[]"
+	if len(rows) > 0 {
+		table = fmt.Sprintf("[[path value description]; %s]", strings.Join(rows, " "))
+	}
+
+	return fmt.Sprintf(`def "nu-complete %s" [context: string] {
+  let words = ($context | split row " " | where {|w| $w != ""})
+  let path = if ($context | str ends-with " ") { $words } else { $words | drop 1 }
+  %s | where path == ($path | str join ".") | select value description
+}
+`, cmd.Alias, table), nil
+}
+
+func (nushell) WrapperFunc() string {
+	return `def --wrapped nostromo [...args: string@"nu-complete nostromo"] { ^nostromo ...$args }
+def --wrapped __nostromo_run [name: string, ...args] { let result = (^nostromo eval $name ...$args | complete); if $result.exit_code == 127 { run-external $name ...$args } else { ^sh -c $result.stdout } }`
+}
+
+func (nushell) AliasFuncs(m *model.Manifest) string {
+	var aliases []string
+	for _, c := range sortedCommands(m) {
+		var alias string
+		if IsPlainAlias(c) {
+			cmd := buildDirSubshell(c.Dir, fmt.Sprintf("%s \"$@\"", c.Name))
+			alias = fmt.Sprintf("def --wrapped %s [...args] { ^sh -c %s %s ...$args }", c.Alias, nuDoubleQuote(cmd), c.Alias)
+		} else {
+			alias = fmt.Sprintf("def --wrapped %s [...args: string@\"nu-complete %s\"] { __nostromo_run %s ...$args }", c.Alias, c.Alias, c.Alias)
+		}
+		aliases = append(aliases, alias)
+	}
+	return fmt.Sprintf("\n%s\n", strings.Join(aliases, "\n"))
+}
+
+// sortedCommands at the root of the manifest by alias so the startup file
+// only changes when commands do
+func sortedCommands(m *model.Manifest) []*model.Command {
+	var aliases []string
+	for alias := range m.Commands {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var commands []*model.Command
+	for _, alias := range aliases {
+		commands = append(commands, m.Commands[alias])
+	}
+	return commands
+}
+
+// nuCobraCompleter asks the cobra program to complete itself
+func nuCobraCompleter(name string) string {
+	return fmt.Sprintf(`def "nu-complete %s" [context: string] {
+  let words = ($context | split row " " | skip 1)
+  ^%s __complete ...$words | lines | where {|l| not ($l | str starts-with ":")} | each {|l|
+    let parts = ($l | split row "\t")
+    {value: ($parts | first), description: ($parts | skip 1 | str join " ")}
+  }
+}`, name, name)
+}
+
+// nuDoubleQuote a string escaping characters that are special within double
+// quotes in nushell
+func nuDoubleQuote(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"")
+	return "\"" + r.Replace(s) + "\""
+}
@@ -0,0 +1,77 @@
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestNushellSource(t *testing.T) {
+	m := model.NewManifest()
+	m.AddCommand("build.ios", "xcodebuild", "Build \"ios\" app", &model.Code{}, false, "concatenate", "")
+	m.AddCommand("ll", "ls -la", "", &model.Code{}, true, "concatenate", "/tmp")
+
+	got := nushell{}.Source(m)
+	for _, want := range []string{
+		`def "nu-complete nostromo" [context: string] {`,
+		`def --wrapped nostromo [...args: string@"nu-complete nostromo"] { ^nostromo ...$args }`,
+		`def --wrapped build [...args: string@"nu-complete build"] { __nostromo_run build ...$args }`,
+		`def --wrapped ll [...args] { ^sh -c "(cd \"/tmp\" || exit 1; ls -la \"$@\")" ll ...$args }`,
+		`[[path value description]; ["build" "ios" "Build \"ios\" app"]]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Source() = %v, want to contain %v", got, want)
+		}
+	}
+}
+
+func TestNushellSync(t *testing.T) {
+	home, cleanup := fakeBackupHome(t)
+	defer cleanup()
+
+	path := filepath.Join(home, ".config", "nushell", "config.nu")
+	content := "$env.config.show_banner = false\n" + nushell{}.Comment(beginBlock) + "\n" + nushell{}.Comment(endBlock) + "\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(files []*startupFile) { initFiles = files }(initFiles)
+	initFiles = loadStartupFiles()
+
+	m := model.NewManifest()
+	tests := []struct {
+		name   string
+		change func()
+		want   string
+		exists bool
+	}{
+		{"add", func() { m.AddCommand("build", "make", "", &model.Code{}, false, "concatenate", "") }, "def --wrapped build ", true},
+		{"remove", func() { m.RemoveCommand("build") }, "def --wrapped build ", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			if err := Sync(m); err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); strings.Contains(got, tt.want) != tt.exists {
+				t.Errorf("Sync() wrote %v, want to contain %v: %v", got, tt.want, tt.exists)
+			}
+			if !strings.HasPrefix(string(b), "$env.config.show_banner = false\n") {
+				t.Errorf("Sync() didn't keep content outside the nostromo section")
+			}
+		})
+	}
+}
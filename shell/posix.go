@@ -0,0 +1,173 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/pathutil"
+	"github.com/spf13/cobra"
+)
+
+const sourceCompletion = "eval \"$(nostromo completion)\""
+
+// posix is the base for targets using POSIX shell syntax
+type posix struct{}
+
+func (posix) Comment(line string) string {
+	return "# " + line
+}
+
+func (posix) Source(m *model.Manifest) string {
+	return sourceCompletion
+}
+
+// WrapperFunc for nostromo and __nostromo_run which root commands dispatch
+// through, it runs the executable of the same name when the current
+// directory has no such command
+func (posix) WrapperFunc() string {
+	return `__nostromo_cmd() { command nostromo $*; }
+nostromo() { __nostromo_cmd $* && eval "$(__nostromo_cmd completion)"; }
+__nostromo_run() { local __name=$1 __cmd __code; shift; __cmd=$(__nostromo_cmd eval "$__name" "$*"); __code=$?; case $__code in 0) eval "$__cmd" ;; 127) command "$__name" "$@" ;; *) eval "$__cmd"; return $__code ;; esac; }`
+}
+
+func (posix) AliasFuncs(m *model.Manifest) string {
+	var aliases []string
+	for _, c := range m.Commands {
+		var alias string
+		if IsPlainAlias(c) && len(c.Dir) > 0 {
+			// Aliases can only append arguments after the subshell
+			alias = fmt.Sprintf("%s() { %s; }", c.Alias, buildDirSubshell(c.Dir, c.Name+` "$@"`))
+		} else if IsPlainAlias(c) {
+			alias = fmt.Sprintf("alias %s='%s'", c.Alias, c.Name)
+		} else {
+			alias = fmt.Sprintf("%s() { __nostromo_run %s \"$@\"; }", c.Alias, c.Alias)
+		}
+		aliases = append(aliases, alias)
+	}
+	return fmt.Sprintf("\n%s\n", strings.Join(aliases, "\n"))
+}
+
+func (posix) EnvBlock(env map[string]string, cmd string) string {
+	return buildEnvSubshell(env, cmd)
+}
+
+func (posix) DirBlock(dir, cmd string) string {
+	return buildDirSubshell(dir, cmd)
+}
+
+type bash struct {
+	posix
+}
+
+func (bash) Shell() Shell {
+	return Bash
+}
+
+func (bash) Names() []string {
+	return []string{"bash"}
+}
+
+func (bash) StartupFiles() []StartupFilename {
+	return []StartupFilename{
+		{Name: ".profile"},
+		{Name: ".bash_profile"},
+		{Name: ".bashrc", Preferred: true},
+	}
+}
+
+func (bash) Completion(cmd *cobra.Command) (string, error) {
+	return cobraCompletion(cmd.GenBashCompletion)
+}
+
+func (t bash) CommandCompletion(cmd *model.Command) (string, error) {
+	return t.Completion(cmd.CobraCommand())
+}
+
+type zsh struct {
+	posix
+}
+
+func (zsh) Shell() Shell {
+	return Zsh
+}
+
+func (zsh) Names() []string {
+	return []string{"zsh"}
+}
+
+func (zsh) StartupFiles() []StartupFilename {
+	return []StartupFilename{
+		{Name: ".zshrc", Preferred: true},
+	}
+}
+
+func (zsh) Completion(cmd *cobra.Command) (string, error) {
+	s, err := cobraCompletion(cmd.GenZshCompletion)
+	if err != nil {
+		return "", err
+	}
+
+	// This is required due to a bug in cobra and zsh support:
+	// https://github.com/spf13/cobra/pull/887
+	return strings.Replace(s, "#", "", 1), nil
+}
+
+func (t zsh) CommandCompletion(cmd *model.Command) (string, error) {
+	return t.Completion(cmd.CobraCommand())
+}
+
+// cobraCompletion script from a cobra generator
+func cobraCompletion(gen func(w io.Writer) error) (string, error) {
+	var buf bytes.Buffer
+	if err := gen(&buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// buildEnvSubshell wraps the command in a subshell exporting variables in
+// sorted order so they don't leak into the shell evaluating it. Values are
+// double quoted so they can still reference other variables like `$HOME`.
+func buildEnvSubshell(env map[string]string, cmd string) string {
+	if len(env) == 0 {
+		return cmd
+	}
+
+	var exports []string
+	for _, name := range sortedNames(env) {
+		exports = append(exports, fmt.Sprintf("%s=%s", name, doubleQuote(env[name])))
+	}
+
+	return fmt.Sprintf("(export %s; %s)", strings.Join(exports, " "), cmd)
+}
+
+// buildDirSubshell wraps the command in a subshell that changes to the
+// directory first, the shell's own working directory is left untouched.
+func buildDirSubshell(dir, cmd string) string {
+	if len(dir) == 0 {
+		return cmd
+	}
+
+	return fmt.Sprintf("(cd %s || exit 1; %s)", doubleQuote(pathutil.Expand(dir)), cmd)
+}
+
+// doubleQuote a string escaping characters that are special within double
+// quotes other than `$`
+func doubleQuote(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "`", "\\`")
+	return "\"" + r.Replace(s) + "\""
+}
+
+// sortedNames of variables in the environment
+func sortedNames(env map[string]string) []string {
+	var names []string
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,71 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+	"github.com/spf13/cobra"
+)
+
+const sourcePowerShellCompletion = "nostromo completion --shell pwsh | Out-String | Invoke-Expression"
+
+// powershell targets PowerShell Core profiles. Commands are run with `sh`
+// since they are written in POSIX shell syntax.
+type powershell struct {
+	posix
+}
+
+func (powershell) Shell() Shell {
+	return PowerShell
+}
+
+func (powershell) Names() []string {
+	return []string{"pwsh", "powershell"}
+}
+
+func (powershell) StartupFiles() []StartupFilename {
+	return []StartupFilename{
+		{Name: ".config/powershell/Microsoft.PowerShell_profile.ps1", Preferred: true, ConfigDir: ".config/powershell"},
+	}
+}
+
+func (powershell) Source(m *model.Manifest) string {
+	return sourcePowerShellCompletion
+}
+
+func (powershell) Completion(cmd *cobra.Command) (string, error) {
+	return cobraCompletion(cmd.GenPowerShellCompletion)
+}
+
+func (t powershell) CommandCompletion(cmd *model.Command) (string, error) {
+	return t.Completion(cmd.CobraCommand())
+}
+
+// WrapperFunc defines functions globally since they are created by
+// evaluating completions from within a function
+func (powershell) WrapperFunc() string {
+	return `function global:__nostromo_cmd { & (Get-Command -Name nostromo -CommandType Application | Select-Object -First 1) @args }
+function global:nostromo { __nostromo_cmd @args; if ($?) { __nostromo_cmd completion --shell pwsh | Out-String | Invoke-Expression } }
+function global:__nostromo_run { $name = $args[0]; $rest = @($args | Select-Object -Skip 1); $cmd = __nostromo_cmd eval $name @rest | Out-String; if ($LASTEXITCODE -eq 127) { & (Get-Command -Name $name -CommandType Application | Select-Object -First 1) @rest } else { sh -c $cmd } }`
+}
+
+func (powershell) AliasFuncs(m *model.Manifest) string {
+	var aliases []string
+	for _, c := range m.Commands {
+		var alias string
+		if IsPlainAlias(c) {
+			cmd := buildDirSubshell(c.Dir, fmt.Sprintf("%s \"$@\"", c.Name))
+			alias = fmt.Sprintf("function global:%s { sh -c %s %s @args }", c.Alias, psSingleQuote(cmd), c.Alias)
+		} else {
+			alias = fmt.Sprintf("function global:%s { __nostromo_run %s @args }", c.Alias, c.Alias)
+		}
+		aliases = append(aliases, alias)
+	}
+	return fmt.Sprintf("\n%s\n", strings.Join(aliases, "\n"))
+}
+
+// psSingleQuote a string so it is taken literally by PowerShell
+func psSingleQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
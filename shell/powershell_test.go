@@ -0,0 +1,37 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestPowerShellAliasFuncs(t *testing.T) {
+	m := model.NewManifest()
+	m.AddCommand("build", "make", "", &model.Code{}, false, "concatenate", "")
+	m.AddCommand("hi", "echo 'hi'", "", &model.Code{}, true, "concatenate", "")
+
+	got := powershell{}.AliasFuncs(m)
+	for _, want := range []string{
+		"function global:build { __nostromo_run build @args }",
+		`function global:hi { sh -c 'echo ''hi'' "$@"' hi @args }`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("AliasFuncs() = %v, want to contain %v", got, want)
+		}
+	}
+}
+
+func TestPowerShellCompletion(t *testing.T) {
+	m := model.NewManifest()
+	m.AddCommand("build.ios", "xcodebuild", "", &model.Code{}, false, "concatenate", "")
+
+	got, err := powershell{}.CommandCompletion(m.Find("build"))
+	if err != nil {
+		t.Errorf("CommandCompletion() error = %v", err)
+	}
+	if !strings.Contains(got, "Register-ArgumentCompleter") || !strings.Contains(got, "'build'") {
+		t.Errorf("CommandCompletion() = %v, want argument completer for build", got)
+	}
+}
@@ -0,0 +1,116 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+)
+
+// forwardedSignals are sent on to commands run directly
+//
+// Interrupts from the terminal already reach the whole foreground process
+// group, so they are only caught to keep nostromo waiting for the command.
+var forwardedSignals = []os.Signal{syscall.SIGHUP, syscall.SIGTERM}
+
+var caughtSignals = append([]os.Signal{os.Interrupt, syscall.SIGQUIT}, forwardedSignals...)
+
+// Run the command directly using the user's shell returning its exit code
+//
+// Stdio is passed through and signals sent to nostromo are forwarded, an
+// error is only returned if the command couldn't be started.
+func Run(e *model.Execution, verbose bool) (int, error) {
+	if e == nil || len(e.Command) == 0 {
+		return -1, fmt.Errorf("cannot run empty command")
+	}
+
+	command := strings.TrimSuffix(e.Command, "\n")
+
+	t := TargetFor(Bash)
+	cmdStr, err := buildExecCmd(e, command, true)
+	if err != nil {
+		return -1, err
+	}
+	cmdStr = t.EnvBlock(e.Env, cmdStr)
+	cmdStr = t.DirBlock(e.Dir, cmdStr)
+
+	sh := runShell()
+	if verbose {
+		log.Debugf("running: %s -c %s\n", sh, cmdStr)
+	}
+
+	cmd := exec.Command(sh, "-c", cmdStr)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, caughtSignals...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return -1, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				for _, s := range forwardedSignals {
+					if sig == s {
+						cmd.Process.Signal(sig)
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	if err == nil {
+		return 0, nil
+	}
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return -1, err
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		// Match the shell convention for commands killed by a signal
+		return 128 + int(status.Signal()), nil
+	}
+	return exitErr.ExitCode(), nil
+}
+
+// RunString delegates running the command at args to `nostromo run` for
+// commands that opted out of being evaluated by the shell
+func RunString(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = singleQuote(arg)
+	}
+	return strings.TrimSpace("command nostromo run " + strings.Join(quoted, " "))
+}
+
+// posixShells understand the POSIX shell syntax commands are written in
+var posixShells = []string{"bash", "zsh", "sh", "dash", "ksh"}
+
+// runShell for commands, the user's shell is used if it's a POSIX shell
+func runShell() string {
+	sh := os.Getenv("SHELL")
+	for _, name := range posixShells {
+		if len(sh) > 0 && filepath.Base(sh) == name {
+			return sh
+		}
+	}
+	return "sh"
+}
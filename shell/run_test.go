@@ -0,0 +1,177 @@
+//go:build !windows
+// +build !windows
+
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		execution *model.Execution
+		expErr    bool
+		expected  int
+	}{
+		{"nil execution", nil, true, -1},
+		{"empty command", &model.Execution{}, true, -1},
+		{"success", &model.Execution{Command: "true"}, false, 0},
+		{"exit code", &model.Execution{Command: "exit 3"}, false, 3},
+		{"env and dir", &model.Execution{Command: `test "$FOO" = bar && test "$(pwd)" = /`, Env: map[string]string{"FOO": "bar"}, Dir: "/"}, false, 0},
+		{"killed", &model.Execution{Command: "kill -TERM $$"}, false, 143},
+	}
+
+	shell := os.Getenv("SHELL")
+	defer os.Setenv("SHELL", shell)
+	os.Setenv("SHELL", "/bin/sh")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := Run(test.execution, false)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if actual != test.expected {
+				t.Errorf("expected: %d, actual: %d", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRunString(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"no args", nil, "command nostromo run"},
+		{"args", []string{"foo", "bar"}, "command nostromo run 'foo' 'bar'"},
+		{"quotes", []string{"it's"}, `command nostromo run 'it'\''s'`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := RunString(test.args); actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEvalStringScopesEnv(t *testing.T) {
+	os.Setenv("NOSTROMO_SHELL", "bash")
+	defer os.Unsetenv("NOSTROMO_SHELL")
+
+	tests := []struct {
+		name      string
+		execution *model.Execution
+	}{
+		{"env", &model.Execution{Command: `echo "$FOO"`, Env: map[string]string{"FOO": "bar"}}},
+		{"env and dir", &model.Execution{Command: `echo "$FOO"`, Env: map[string]string{"FOO": "bar"}, Dir: "/"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmdStr, err := EvalString(test.execution, false)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			out, err := exec.Command("/bin/sh", "-c", cmdStr+`; echo "${FOO-unset}"`).Output()
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if actual, expected := strings.TrimSpace(string(out)), "bar\nunset"; actual != expected {
+				t.Errorf("expected: %q, actual: %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestPosixAliasFuncs(t *testing.T) {
+	m := model.NewManifest()
+	m.AddCommand("foo", "echo foo", "", &model.Code{}, false, "concatenate", "")
+	m.AddCommand("ll", "ls -la", "", &model.Code{}, true, "concatenate", "")
+	m.AddCommand("lt", "ls -t", "", &model.Code{}, true, "concatenate", "/tmp")
+	m.SetSource("global")
+	local := model.NewManifest()
+	local.AddCommand("la", "ls -a", "", &model.Code{}, true, "concatenate", "")
+	local.SetSource("/src/app/.nostromo.yaml")
+	m.Merge(local)
+
+	got := bash{}.AliasFuncs(m)
+	for _, want := range []string{
+		`foo() { __nostromo_run foo "$@"; }`,
+		"alias ll='ls -la'",
+		`lt() { (cd "/tmp" || exit 1; ls -t "$@"); }`,
+		`la() { __nostromo_run la "$@"; }`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("AliasFuncs() = %v, want to contain %v", got, want)
+		}
+	}
+}
+
+func TestPosixRunFallback(t *testing.T) {
+	sh, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	dir, err := ioutil.TempDir("", "nostromo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Fake nostromo resolving only `foo`, failing `baz` with an echoed error
+	// and an executable sharing the name of a command that isn't found
+	scripts := map[string]string{
+		"nostromo": "#!/bin/sh\ncase $2 in foo) echo 'echo managed' ;; baz) echo 'echo \"error: failed\";'; exit 255 ;; *) exit 127 ;; esac\n",
+		"bar":      "#!/bin/sh\necho \"executable $*\"\n",
+	}
+	for name, script := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := model.NewManifest()
+	m.AddCommand("foo", "echo foo", "", &model.Code{}, false, "concatenate", "")
+	m.AddCommand("bar", "echo bar", "", &model.Code{}, false, "concatenate", "")
+	m.AddCommand("baz", "echo baz", "", &model.Code{}, false, "concatenate", "")
+
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{"found", "foo", "managed"},
+		{"not found", "bar one two", "executable one two"},
+		{"failed", "baz; echo $?", "error: failed\n255"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			script := bash{}.WrapperFunc() + "\n" + bash{}.AliasFuncs(m) + test.command
+			cmd := exec.Command(sh, "-c", script)
+			cmd.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if actual := strings.TrimSpace(string(out)); actual != test.expected {
+				t.Errorf("expected: %q, actual: %q", test.expected, actual)
+			}
+		})
+	}
+}
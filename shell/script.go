@@ -0,0 +1,276 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/pathutil"
+)
+
+// interpreter runs code snippets for a language
+type interpreter struct {
+	command   string // command running the interpreter
+	inline    string // flag passing the code as an argument
+	file      bool   // always run from a script file
+	extension string // extension for script files
+}
+
+// builtinLanguages are supported without any configuration
+var builtinLanguages = []string{"ruby", "python", "perl", "js"}
+
+var builtinInterpreters = map[string]interpreter{
+	"ruby":   {"ruby", "-e", false, ".rb"},
+	"python": {"python", "-c", false, ".py"},
+	"perl":   {"perl", "-e", false, ".pl"},
+	"js":     {"node", "-e", false, ".js"},
+}
+
+var (
+	languages    = builtinLanguages
+	interpreters = builtinInterpreters
+)
+
+// scriptDir holds script files written for snippets run in file mode
+var scriptDir = defaultScriptDir()
+
+// SetLanguages declared in the manifest, these are supported in addition to
+// the built in languages and replace any with the same name
+//
+// Languages without a name or interpreter are ignored.
+func SetLanguages(langs []*model.Language) {
+	languages = append([]string{}, builtinLanguages...)
+	interpreters = map[string]interpreter{}
+	for name, i := range builtinInterpreters {
+		interpreters[name] = i
+	}
+
+	for _, l := range langs {
+		if l == nil || len(l.Name) == 0 || l.Name == "sh" || len(strings.TrimSpace(l.Interpreter)) == 0 {
+			continue
+		}
+		if _, ok := interpreters[l.Name]; !ok {
+			languages = append(languages, l.Name)
+		}
+		interpreters[l.Name] = interpreter{l.Interpreter, l.Flag, l.File, l.Extension}
+	}
+}
+
+// buildEvalCmd wraps code snippets to run inline with their interpreter,
+// the snippet is quoted so it's passed through the shell as is
+//
+// Languages in file mode read the snippet from stdin in place of a script.
+func buildEvalCmd(cmd, language string) string {
+	if _, ok := interpreters[language]; !ok {
+		return cmd
+	}
+	return buildQuotedEvalCmd(singleQuote(cmd), language)
+}
+
+// buildQuotedEvalCmd like buildEvalCmd for code already quoted as a word
+func buildQuotedEvalCmd(code, language string) string {
+	i := interpreters[language]
+	if i.file {
+		return fmt.Sprintf("printf '%%s\\n' %s | %s /dev/stdin", code, i.command)
+	}
+	return joinWords(i.command, i.inline, code)
+}
+
+// buildExecCmd for an execution
+//
+// Single line snippets run inline with arguments appended to the code.
+// Multi-line snippets and languages in file mode are written to a script
+// file that the interpreter runs with arguments quoted as argv, unless
+// write is false where only its path is used.
+func buildExecCmd(e *model.Execution, cmd string, write bool) (string, error) {
+	if len(e.Script) > 0 {
+		words := []string{buildScriptCmd(e.Script, e.Language)}
+		for _, arg := range e.Args {
+			words = append(words, singleQuote(arg))
+		}
+		return joinWords(words...), nil
+	}
+
+	i, ok := interpreters[e.Language]
+	if !ok {
+		return joinWords(append([]string{cmd}, e.Args...)...), nil
+	}
+	if !i.file && !strings.Contains(cmd, "\n") {
+		return buildEvalCmd(joinWords(append([]string{cmd}, e.Args...)...), e.Language), nil
+	}
+
+	path := scriptPath(cmd, i.extension)
+	if write {
+		var err error
+		if path, err = writeScript(cmd, i.extension); err != nil {
+			return "", err
+		}
+	}
+
+	words := []string{i.command, singleQuote(path)}
+	for _, arg := range e.Args {
+		words = append(words, singleQuote(arg))
+	}
+	return joinWords(words...), nil
+}
+
+// buildScriptCmd runs the script file at path with the interpreter for the
+// language, shell scripts use the interpreter in their shebang if they have one
+func buildScriptCmd(path, language string) string {
+	if i, ok := interpreters[language]; ok {
+		return joinWords(i.command, singleQuote(path))
+	}
+
+	words := shebang(path)
+	if len(words) == 0 {
+		words = []string{"sh"}
+	}
+	var quoted []string
+	for _, w := range words {
+		quoted = append(quoted, singleQuote(w))
+	}
+	return joinWords(append(quoted, singleQuote(path))...)
+}
+
+// ScriptLanguage infers the language of the script file at path from the
+// interpreter in its shebang or else its extension
+//
+// The shebang is what runs the script when executed directly so it wins
+// over an extension that may not match, like a python script ending in .sh.
+func ScriptLanguage(path string) (string, error) {
+	if language := shebangLanguage(path); len(language) > 0 {
+		return language, nil
+	}
+
+	ext := filepath.Ext(path)
+	if ext == ".sh" {
+		return "sh", nil
+	}
+	if len(ext) > 0 {
+		for _, name := range languages {
+			if interpreters[name].extension == ext {
+				return name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("unable to infer language of %s, supported languages: %s", path, SupportedLanguages())
+}
+
+// shebangLanguage of the script at path or empty if it has no shebang with
+// a supported interpreter
+func shebangLanguage(path string) string {
+	words := shebang(path)
+	if len(words) == 0 {
+		return ""
+	}
+
+	program := filepath.Base(words[0])
+	if program == "env" {
+		program = ""
+		for _, w := range words[1:] {
+			if !strings.HasPrefix(w, "-") && !strings.Contains(w, "=") {
+				program = filepath.Base(w)
+				break
+			}
+		}
+	}
+	for _, sh := range posixShells {
+		if program == sh {
+			return "sh"
+		}
+	}
+	for _, name := range languages {
+		fields := strings.Fields(interpreters[name].command)
+		if program == name || (len(fields) > 0 && program == filepath.Base(fields[0])) {
+			return name
+		}
+	}
+	// Match versioned interpreters like python3 to their language
+	for _, name := range languages {
+		if strings.TrimRight(program, "0123456789.") == name {
+			return name
+		}
+	}
+	return ""
+}
+
+// shebang interpreter and arguments of the script at path if it has one
+func shebang(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	if !strings.HasPrefix(line, "#!") {
+		return nil
+	}
+	return strings.Fields(line[2:])
+}
+
+// joinWords with spaces skipping empty ones
+func joinWords(words ...string) string {
+	var nonEmpty []string
+	for _, w := range words {
+		if len(w) > 0 {
+			nonEmpty = append(nonEmpty, w)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+// defaultScriptDir in the user's cache directory, scripts are private to
+// the user so they can't be replaced by anyone else before they run
+func defaultScriptDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "nostromo", "scripts")
+	}
+	return pathutil.Abs("~/.nostromo/scripts")
+}
+
+// writeScript for the code returning its path
+//
+// Files are named after a hash of their contents so repeated runs reuse the
+// same script and scripts still being run by the shell are never replaced.
+// Existing files are only reused if they still hold the code, anything else
+// at the path is replaced.
+func writeScript(code, extension string) (string, error) {
+	if err := os.MkdirAll(scriptDir, 0700); err != nil {
+		return "", err
+	}
+	if err := checkScriptDir(scriptDir); err != nil {
+		return "", fmt.Errorf("unable to use script directory: %s", err)
+	}
+
+	path := scriptPath(code, extension)
+	contents := []byte(code + "\n")
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().IsRegular() {
+			if b, err := ioutil.ReadFile(path); err == nil && bytes.Equal(b, contents) {
+				return path, nil
+			}
+		} else if err := os.Remove(path); err != nil {
+			// Links would be followed when writing
+			return "", fmt.Errorf("unable to write script: %s", err)
+		}
+	}
+
+	if err := pathutil.WriteFile(path, contents, 0600); err != nil {
+		return "", fmt.Errorf("unable to write script: %s", err)
+	}
+	return path, nil
+}
+
+func scriptPath(code, extension string) string {
+	sum := sha256.Sum256([]byte(code))
+	return filepath.Join(scriptDir, hex.EncodeToString(sum[:8])+extension)
+}
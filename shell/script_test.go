@@ -0,0 +1,177 @@
+package shell
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestSetLanguages(t *testing.T) {
+	defer SetLanguages(nil)
+
+	SetLanguages([]*model.Language{
+		{Name: "lua", Interpreter: "lua", Flag: "-e", Extension: ".lua"},
+		{Name: "python", Interpreter: "python3", Flag: "-c", Extension: ".py"},
+		{Name: "missing", Flag: "-e"},
+		{Name: "sh", Interpreter: "bash"},
+		nil,
+	})
+
+	expected := []string{"sh", "ruby", "python", "perl", "js", "lua"}
+	if actual := SupportedLanguages(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if !IsSupportedLanguage("lua") || IsSupportedLanguage("missing") {
+		t.Errorf("expected lua to be supported and missing to not be")
+	}
+	if actual := buildEvalCmd("print()", "python"); actual != "python3 -c 'print()'" {
+		t.Errorf("expected python to be replaced but got %s", actual)
+	}
+
+	SetLanguages([]*model.Language{{Name: "awk", Interpreter: "awk -f", File: true}})
+	if actual := buildEvalCmd("{ print NF }", "awk"); actual != `printf '%s\n' '{ print NF }' | awk -f /dev/stdin` {
+		t.Errorf("expected awk to read from stdin but got %s", actual)
+	}
+
+	SetLanguages(nil)
+	if IsSupportedLanguage("lua") {
+		t.Errorf("expected lua to be unsupported after reset")
+	}
+}
+
+func TestBuildExecCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo-script")
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { scriptDir = d }(scriptDir)
+	scriptDir = dir
+
+	defer SetLanguages(nil)
+	SetLanguages([]*model.Language{
+		{Name: "jq", Interpreter: "jq"},
+		{Name: "awk", Interpreter: "awk -f", File: true, Extension: ".awk"},
+	})
+
+	tests := []struct {
+		name     string
+		e        *model.Execution
+		ext      string
+		expected string
+	}{
+		{"sh", &model.Execution{Command: "echo 'foo'", Language: "sh"}, "", "echo 'foo'"},
+		{"inline", &model.Execution{Command: "puts 'foo'", Language: "ruby"}, "", `ruby -e 'puts '\''foo'\'''`},
+		{"inline args", &model.Execution{Command: "puts", Language: "ruby", Args: []string{"'foo'"}}, "", `ruby -e 'puts '\''foo'\'''`},
+		{"inline no flag", &model.Execution{Command: ".foo", Language: "jq"}, "", "jq '.foo'"},
+		{"script", &model.Execution{Command: "x = 1\nprint(x)", Language: "python"}, ".py", "python '%s'"},
+		{"script args", &model.Execution{Command: "print 1;\nprint 2;", Language: "perl", Args: []string{"it's", "$HOME"}}, ".pl", `perl '%s' 'it'\''s' '$HOME'`},
+		{"script file", &model.Execution{Command: "/x/bar.rb", Script: "/x/bar.rb", Language: "ruby", Args: []string{"a b"}}, "", "ruby '/x/bar.rb' 'a b'"},
+		{"shell script", &model.Execution{Command: "/x/bar.sh", Script: "/x/bar.sh", Language: "sh"}, "", "'sh' '/x/bar.sh'"},
+		{"file mode", &model.Execution{Command: "{ print $1 }", Language: "awk", Args: []string{"foo.txt"}}, ".awk", "awk -f '%s' 'foo.txt'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := buildExecCmd(test.e, test.e.Command, true)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			expected := test.expected
+			if len(test.ext) > 0 {
+				path := scriptPath(test.e.Command, test.ext)
+				expected = fmt.Sprintf(test.expected, path)
+
+				b, err := ioutil.ReadFile(path)
+				if err != nil {
+					t.Fatalf("expected no error but got %s", err)
+				}
+				if string(b) != test.e.Command+"\n" {
+					t.Errorf("expected script: %q, actual: %q", test.e.Command+"\n", string(b))
+				}
+			}
+			if actual != expected {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestExplainString(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo-script")
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { scriptDir = d }(scriptDir)
+	scriptDir = filepath.Join(dir, "scripts")
+
+	e := &model.Execution{Command: "x = 1\nprint(x)", Language: "python"}
+	actual, err := ExplainString(e)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	path := scriptPath(e.Command, ".py")
+	if expected := fmt.Sprintf("python '%s'", path); actual != expected {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+	if _, err := os.Stat(scriptDir); !os.IsNotExist(err) {
+		t.Errorf("expected no script directory to be written")
+	}
+}
+
+func TestScriptLanguage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo-script")
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer SetLanguages(nil)
+	SetLanguages([]*model.Language{{Name: "lua", Interpreter: "lua5.4", Flag: "-e", Extension: ".lua"}})
+
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		expected string
+		expErr   bool
+	}{
+		{"extension", "a.py", "", "python", false},
+		{"custom extension", "a.lua", "", "lua", false},
+		{"shell extension", "a.sh", "", "sh", false},
+		{"shebang", "a", "#!/usr/bin/ruby\n", "ruby", false},
+		{"env shebang", "b", "#!/usr/bin/env -S node --harmony\n", "js", false},
+		{"versioned shebang", "c", "#!/usr/bin/env python3\n", "python", false},
+		{"interpreter shebang", "d", "#!/usr/bin/lua5.4\n", "lua", false},
+		{"shell shebang", "e", "#!/bin/bash\n", "sh", false},
+		{"shebang over extension", "g.sh", "#!/usr/bin/env python3\n", "python", false},
+		{"unknown shebang", "h.rb", "#!/usr/bin/env deno\n", "ruby", false},
+		{"unknown", "f.txt", "hello\n", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.file)
+			if err := ioutil.WriteFile(path, []byte(test.contents), 0644); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			actual, err := ScriptLanguage(path)
+			if test.expErr && err == nil {
+				t.Errorf("expected error but got none")
+			} else if !test.expErr && err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
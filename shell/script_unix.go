@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package shell
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkScriptDir is a real directory owned by the current user that only
+// they can access, group and other permissions are removed if set
+func checkScriptDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is not owned by the current user", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return os.Chmod(dir, 0700)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+//go:build !windows
+// +build !windows
+
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteScript(t *testing.T) {
+	root, err := ioutil.TempDir("", "nostromo-script")
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	defer os.RemoveAll(root)
+	defer func(d string) { scriptDir = d }(scriptDir)
+
+	other := filepath.Join(root, "other")
+	if err := ioutil.WriteFile(other, []byte("other"), 0600); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	const code = "print(1)"
+	tests := []struct {
+		name   string
+		setup  func(dir, path string) error
+		expErr bool
+	}{
+		{"new", func(dir, path string) error { return nil }, false},
+		{"loose permissions", func(dir, path string) error {
+			return os.Mkdir(dir, 0755)
+		}, false},
+		{"changed script", func(dir, path string) error {
+			if err := os.Mkdir(dir, 0700); err != nil {
+				return err
+			}
+			return ioutil.WriteFile(path, []byte("print(2)\n"), 0600)
+		}, false},
+		{"linked script", func(dir, path string) error {
+			if err := os.Mkdir(dir, 0700); err != nil {
+				return err
+			}
+			return os.Symlink(other, path)
+		}, false},
+		{"linked dir", func(dir, path string) error {
+			return os.Symlink(root, dir)
+		}, true},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scriptDir = filepath.Join(root, string(rune('a'+i)))
+			if err := test.setup(scriptDir, scriptPath(code, ".py")); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			path, err := writeScript(code, ".py")
+			if err != nil && !test.expErr {
+				t.Fatalf("expected no error but got %s", err)
+			} else if err == nil && test.expErr {
+				t.Fatalf("expected error but got none")
+			}
+			if test.expErr {
+				return
+			}
+
+			if info, err := os.Stat(scriptDir); err != nil {
+				t.Errorf("expected no error but got %s", err)
+			} else if info.Mode().Perm() != 0700 {
+				t.Errorf("expected dir mode %v, actual: %v", os.FileMode(0700), info.Mode().Perm())
+			}
+			if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+				t.Errorf("expected regular script file: %v", err)
+			}
+			if b, _ := ioutil.ReadFile(path); string(b) != code+"\n" {
+				t.Errorf("expected script: %q, actual: %q", code+"\n", string(b))
+			}
+			if b, _ := ioutil.ReadFile(other); string(b) != "other" {
+				t.Errorf("expected linked file to be untouched, actual: %q", string(b))
+			}
+		})
+	}
+}
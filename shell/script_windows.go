@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package shell
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkScriptDir is a real directory, ownership is left to the user's
+// profile permissions on windows
+func checkScriptDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/pokanop/nostromo/log"
@@ -16,9 +15,26 @@ type Shell int
 const (
 	Bash Shell = iota
 	Zsh
+	Fish
+	Nushell
+	PowerShell
 )
 
-var validLanguages = []string{"sh", "ruby", "python", "perl", "js"}
+func (s Shell) String() string {
+	switch s {
+	case Bash:
+		return "bash"
+	case Zsh:
+		return "zsh"
+	case Fish:
+		return "fish"
+	case Nushell:
+		return "nu"
+	case PowerShell:
+		return "pwsh"
+	}
+	return "unknown"
+}
 
 var (
 	initFiles = loadStartupFiles()
@@ -27,14 +43,30 @@ var (
 )
 
 // EvalString returns the command as a string to evaluate or an error.
-func EvalString(command, language string, verbose bool) (string, error) {
-	if len(command) == 0 {
+func EvalString(e *model.Execution, verbose bool) (string, error) {
+	return evalString(e, verbose, true)
+}
+
+// ExplainString returns the command EvalString would without writing any
+// script files it runs.
+func ExplainString(e *model.Execution) (string, error) {
+	return evalString(e, false, false)
+}
+
+func evalString(e *model.Execution, verbose, write bool) (string, error) {
+	if e == nil || len(e.Command) == 0 {
 		return "", fmt.Errorf("cannot run empty command")
 	}
 
-	command = strings.TrimSuffix(command, "\n")
+	command := strings.TrimSuffix(e.Command, "\n")
 
-	cmdStr := buildEvalCmd(command, language)
+	t := TargetFor(Which())
+	cmdStr, err := buildExecCmd(e, command, write)
+	if err != nil {
+		return "", err
+	}
+	cmdStr = t.EnvBlock(e.Env, cmdStr)
+	cmdStr = t.DirBlock(e.Dir, cmdStr)
 	if verbose {
 		log.Debugf("executing: %s\n", cmdStr)
 	}
@@ -48,7 +80,7 @@ func EvalString(command, language string, verbose bool) (string, error) {
 // with manifest's commands.
 func Commit(manifest *model.Manifest) error {
 	if len(prefFiles) == 0 {
-		return fmt.Errorf("could not find preferred init file [%s]", strings.Join(preferredFilenames, ", "))
+		return fmt.Errorf("could not find preferred init file [%s]", strings.Join(preferredFilenames(), ", "))
 	}
 
 	for _, f := range initFiles {
@@ -67,6 +99,33 @@ func Commit(manifest *model.Manifest) error {
 	return nil
 }
 
+// Sync startup files already set up by nostromo whose content depends on
+// the manifest, e.g., nushell which can't evaluate generated code at startup
+//
+// Unlike Commit, files that wouldn't change are left alone so no backups
+// are made when the manifest changes.
+func Sync(manifest *model.Manifest) error {
+	for _, f := range initFiles {
+		if f.pristine {
+			continue
+		}
+
+		if err := f.apply(manifest); err != nil {
+			return err
+		}
+		if f.updatedContent == f.content {
+			continue
+		}
+
+		if err := f.commit(); err != nil {
+			return err
+		}
+		f.content = f.updatedContent
+	}
+
+	return nil
+}
+
 // InitFileLines returns the shell initialization file lines
 func InitFileLines() (string, error) {
 	if prefFile == nil {
@@ -75,63 +134,16 @@ func InitFileLines() (string, error) {
 	return prefFile.contentBlock()
 }
 
-// Which shell is currently running
-func Which() Shell {
-	sh := os.Getenv("SHELL")
-	if strings.Contains(sh, "zsh") {
-		return Zsh
-	}
-	return Bash
-}
-
 // SupportedLanguages that can be executed
 func SupportedLanguages() []string {
-	return validLanguages
+	return append([]string{"sh"}, languages...)
 }
 
 // IsSupportedLanguage returns true if supported snippet language and false otherwise
 func IsSupportedLanguage(language string) bool {
-	for _, l := range validLanguages {
-		if language == l {
-			return true
-		}
-	}
-	return false
-}
-
-func buildEvalCmd(cmd, language string) string {
-	switch language {
-	case "ruby":
-		return fmt.Sprintf("ruby -e '%s'", cmd)
-	case "python":
-		return fmt.Sprintf("python -c '%s'", cmd)
-	case "perl":
-		return fmt.Sprintf("perl -e '%s'", cmd)
-	case "js":
-		return fmt.Sprintf("node -e '%s'", cmd)
-	case "sh":
-		fallthrough
-	default:
-		return cmd
-	}
-}
-
-func shellWrapperFunc() string {
-	return `__nostromo_cmd() { command nostromo $* }
-nostromo() { __nostromo_cmd $* && eval "$(__nostromo_cmd completion)" }`
-}
-
-func shellAliasFuncs(m *model.Manifest) string {
-	var aliases []string
-	for _, c := range m.Commands {
-		var alias string
-		if c.AliasOnly {
-			alias = fmt.Sprintf("alias %s='%s'", c.Alias, c.Name)
-		} else {
-			cmd := fmt.Sprintf("__nostromo_cmd eval %s \"$*\"", c.Alias)
-			alias = strings.TrimSpace(fmt.Sprintf("%s() { eval $(%s) }", c.Alias, cmd))
-		}
-		aliases = append(aliases, alias)
+	if language == "sh" {
+		return true
 	}
-	return fmt.Sprintf("\n%s\n", strings.Join(aliases, "\n"))
+	_, ok := interpreters[language]
+	return ok
 }
@@ -3,6 +3,8 @@ package shell
 import (
 	"reflect"
 	"testing"
+
+	"github.com/pokanop/nostromo/model"
 )
 
 func TestValidLanguages(t *testing.T) {
@@ -46,6 +48,8 @@ func TestEvalString(t *testing.T) {
 	type args struct {
 		command  string
 		language string
+		env      map[string]string
+		dir      string
 	}
 	tests := []struct {
 		name    string
@@ -53,18 +57,24 @@ func TestEvalString(t *testing.T) {
 		want    string
 		wantErr bool
 	}{
-		{"empty command", args{"", ""}, "", true},
-		{"command with newline", args{"echo foo\n", ""}, "echo foo", false},
-		{"no lang", args{"echo foo", ""}, "echo foo", false},
-		{"node", args{"console.log(\"hello world\")", "js"}, "node -e 'console.log(\"hello world\")'", false},
-		{"ruby", args{"puts \"hello world\"", "ruby"}, "ruby -e 'puts \"hello world\"'", false},
-		{"python", args{"print()", "python"}, "python -c 'print()'", false},
-		{"perl", args{"print \"hello world\";", "perl"}, "perl -e 'print \"hello world\";'", false},
-		{"sh", args{"echo foo", "sh"}, "echo foo", false},
+		{"empty command", args{"", "", nil, ""}, "", true},
+		{"command with newline", args{"echo foo\n", "", nil, ""}, "echo foo", false},
+		{"no lang", args{"echo foo", "", nil, ""}, "echo foo", false},
+		{"node", args{"console.log(\"hello world\")", "js", nil, ""}, "node -e 'console.log(\"hello world\")'", false},
+		{"ruby", args{"puts \"hello world\"", "ruby", nil, ""}, "ruby -e 'puts \"hello world\"'", false},
+		{"python", args{"print()", "python", nil, ""}, "python -c 'print()'", false},
+		{"perl", args{"print \"hello world\";", "perl", nil, ""}, "perl -e 'print \"hello world\";'", false},
+		{"sh", args{"echo foo", "sh", nil, ""}, "echo foo", false},
+		{"quoted snippet", args{"puts 'foo'", "ruby", nil, ""}, `ruby -e 'puts '\''foo'\'''`, false},
+		{"env", args{"echo foo", "", map[string]string{"FOO": "foo", "BAR": "$HOME/\"bar\""}, ""}, "(export BAR=\"$HOME/\\\"bar\\\"\" FOO=\"foo\"; echo foo)", false},
+		{"env with lang", args{"print()", "python", map[string]string{"FOO": "foo"}, ""}, "(export FOO=\"foo\"; python -c 'print()')", false},
+		{"dir", args{"echo foo;", "", nil, "/tmp"}, "(cd \"/tmp\" || exit 1; echo foo;)", false},
+		{"dir with env", args{"echo foo", "", map[string]string{"FOO": "foo"}, "$REPO"}, "(cd \"$REPO\" || exit 1; (export FOO=\"foo\"; echo foo))", false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := EvalString(tt.args.command, tt.args.language, true)
+			e := &model.Execution{Command: tt.args.command, Language: tt.args.language, Env: tt.args.env, Dir: tt.args.dir}
+			got, err := EvalString(e, true)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("EvalString() error = %v, wantErr %v", err, tt.wantErr)
 				return
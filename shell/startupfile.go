@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/pokanop/nostromo/log"
 	"github.com/pokanop/nostromo/model"
@@ -14,14 +13,8 @@ import (
 )
 
 const (
-	beginBlockComment = "# nostromo [section begin]"
-	endBlockComment   = "# nostromo [section end]"
-	sourceCompletion  = "eval \"$(nostromo completion)\""
-)
-
-var (
-	startupFilenames   = []string{".profile", ".bash_profile", ".bashrc", ".zshrc"}
-	preferredFilenames = []string{".bashrc", ".zshrc"}
+	beginBlock = "nostromo [section begin]"
+	endBlock   = "nostromo [section end]"
 )
 
 type startupFile struct {
@@ -30,12 +23,13 @@ type startupFile struct {
 	content        string
 	updatedContent string
 	commands       map[string]*model.Command
+	target         Target
 	preferred      bool
 	pristine       bool
 }
 
 func isPreferredFilename(filename string) bool {
-	for _, preferredFilename := range preferredFilenames {
+	for _, preferredFilename := range preferredFilenames() {
 		if strings.Contains(filename, preferredFilename) {
 			return true
 		}
@@ -45,9 +39,16 @@ func isPreferredFilename(filename string) bool {
 
 func loadStartupFiles() []*startupFile {
 	var files []*startupFile
-	for _, n := range startupFilenames {
+	for _, f := range startupFilenames() {
+		n := f.Name
 		path, mode, err := findStartupFile(n)
-		if err != nil {
+		if err != nil && len(f.ConfigDir) > 0 {
+			// Create the file if the shell is configured
+			if s := newConfiguredStartupFile(f); s != nil {
+				files = append(files, s)
+			}
+			continue
+		} else if err != nil {
 			log.Debugf("could not find %s: %s\n", n, err)
 			continue
 		}
@@ -76,15 +77,30 @@ func preferredStartupFiles(files []*startupFile) []*startupFile {
 func currentStartupFile(files []*startupFile) *startupFile {
 	sh := Which()
 	for _, s := range files {
-		if sh == Zsh && strings.Contains(s.path, "zshrc") {
-			return s
-		} else if sh == Bash && strings.Contains(s.path, "bashrc") {
+		if s.preferred && s.target != nil && s.target.Shell() == sh {
 			return s
 		}
 	}
 	return nil
 }
 
+// newConfiguredStartupFile if the shell's config directory exists
+func newConfiguredStartupFile(f StartupFilename) *startupFile {
+	home, err := pathutil.HomeDir()
+	if err != nil {
+		return nil
+	}
+
+	if _, err := os.Stat(filepath.Join(home, f.ConfigDir)); err != nil {
+		log.Debugf("could not find %s: %s\n", f.ConfigDir, err)
+		return nil
+	}
+
+	s := newStartupFile(filepath.Join(home, f.Name), "", 0644)
+	s.pristine = true
+	return s
+}
+
 func findStartupFile(name string) (string, os.FileMode, error) {
 	home, err := pathutil.HomeDir()
 	if err != nil {
@@ -127,6 +143,7 @@ func newStartupFile(path, content string, mode os.FileMode) *startupFile {
 		mode:      mode,
 		content:   content,
 		commands:  map[string]*model.Command{},
+		target:    targetForFilename(path),
 		preferred: isPreferredFilename(path),
 	}
 }
@@ -165,7 +182,7 @@ func (s *startupFile) apply(manifest *model.Manifest) error {
 
 	// Add aliases to preferred init files only
 	if s.preferred {
-		content += s.makeNostromoBlock()
+		content += s.makeNostromoBlock(manifest)
 	}
 
 	s.updatedContent = content
@@ -184,20 +201,23 @@ func (s *startupFile) commit() error {
 	}
 
 	// Save a timestamped backup
-	ts := time.Now().UTC().Format("20060102150405")
-	backupPath := filepath.Join("/tmp", filepath.Base(s.path)) + "_" + ts
-	err := ioutil.WriteFile(backupPath, []byte(s.content), s.mode)
+	err := backupStartupFile(s.path, s.content, s.mode)
 	if err != nil {
 		return err
 	}
 
 	// Save changes
-	err = ioutil.WriteFile(pathutil.Abs(s.path), []byte(s.updatedContent), s.mode)
+	err = os.MkdirAll(filepath.Dir(pathutil.Abs(s.path)), 0755)
+	if err != nil {
+		return err
+	}
+	err = pathutil.WriteFile(s.path, []byte(s.updatedContent), s.mode)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Remember what was written to detect edits outside nostromo
+	return saveChecksum(s.path, s.updatedContent)
 }
 
 func (s *startupFile) contentOmitted() (string, error) {
@@ -233,8 +253,8 @@ func (s *startupFile) contentBlock() (string, error) {
 }
 
 func (s *startupFile) contentIndexes() (int, int) {
-	start := strings.Index(s.content, beginBlockComment)
-	end := strings.Index(s.content, endBlockComment)
+	start := strings.Index(s.content, s.comment(beginBlock))
+	end := strings.Index(s.content, s.comment(endBlock))
 	if start == -1 || end == -1 {
 		// Malformed block
 		return start, end
@@ -242,7 +262,7 @@ func (s *startupFile) contentIndexes() (int, int) {
 
 	// Return adjusted indexes
 	start--
-	end += len(endBlockComment) + 1
+	end += len(s.comment(endBlock)) + 1
 
 	// Adjust if no newline at the end
 	if end > len(s.content) {
@@ -252,6 +272,18 @@ func (s *startupFile) contentIndexes() (int, int) {
 	return start, end
 }
 
-func (s *startupFile) makeNostromoBlock() string {
-	return fmt.Sprintf("\n%s\n%s\n%s\n", beginBlockComment, sourceCompletion, endBlockComment)
+func (s *startupFile) makeNostromoBlock(manifest *model.Manifest) string {
+	source := sourceCompletion
+	if s.target != nil {
+		source = s.target.Source(manifest)
+	}
+	return fmt.Sprintf("\n%s\n%s\n%s\n", s.comment(beginBlock), source, s.comment(endBlock))
+}
+
+// comment a line using the syntax of the shell sourcing this file
+func (s *startupFile) comment(line string) string {
+	if s.target == nil {
+		return "# " + line
+	}
+	return s.target.Comment(line)
 }
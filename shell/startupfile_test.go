@@ -28,6 +28,7 @@ func TestStartupFile(t *testing.T) {
 		{"empty bashrc", ".bashrc", "", model.NewManifest(), true, true, false, false, "\n# nostromo [section begin]\neval \"$(nostromo completion)\"\n# nostromo [section end]\n"},
 		{"empty zshrc", ".zshrc", "", model.NewManifest(), true, true, false, false, "\n# nostromo [section begin]\neval \"$(nostromo completion)\"\n# nostromo [section end]\n"},
 		{"existing non-preferred no commands", ".profile", "export PATH=/usr/local/bin\nexport FOO=bar", model.NewManifest(), false, true, false, false, "export PATH=/usr/local/bin\nexport FOO=bar"},
+		{"empty fish", ".config/fish/conf.d/nostromo.fish", "", model.NewManifest(), true, true, false, false, "\n# nostromo [section begin]\nenv NOSTROMO_SHELL=fish nostromo completion | source\n# nostromo [section end]\n"},
 		{"existing preferred no commands", ".zshrc", "export PATH=/usr/local/bin\nexport FOO=bar", model.NewManifest(), true, true, false, false, "export PATH=/usr/local/bin\nexport FOO=bar\n# nostromo [section begin]\neval \"$(nostromo completion)\"\n# nostromo [section end]\n"},
 	}
 	for _, test := range tests {
@@ -75,6 +76,7 @@ func TestIsPreferredFilename(t *testing.T) {
 		{"zshrc", ".zshrc", true},
 		{"substring 1", "/path/to/.zshrc", true},
 		{"substring 2", "~/.zshrc", true},
+		{"fish", "~/.config/fish/conf.d/nostromo.fish", true},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -120,6 +122,7 @@ func TestCurrentStartupFile(t *testing.T) {
 		{"not startup file", args{"zsh", []*startupFile{makeStartupFileCommon(".foo", "", true)}}, nil},
 		{"zsh", args{"zsh", []*startupFile{makeStartupFileCommon(".zshrc", "", true)}}, makeStartupFileCommon(".zshrc", "", true)},
 		{"bash", args{"bash", []*startupFile{makeStartupFileCommon(".bashrc", "", true)}}, makeStartupFileCommon(".bashrc", "", true)},
+		{"fish", args{"/usr/bin/fish", []*startupFile{makeStartupFileCommon(".bashrc", "", true), makeStartupFileCommon(".config/fish/conf.d/nostromo.fish", "", true)}}, makeStartupFileCommon(".config/fish/conf.d/nostromo.fish", "", true)},
 	}
 	for _, tt := range tests {
 		os.Setenv("SHELL", tt.args.env)
@@ -175,7 +178,7 @@ func makeManifestLong(match bool, aliasOnly bool, cmds ...string) *model.Manifes
 		if !match {
 			cmd = ""
 		}
-		m.AddCommand(alias, cmd, "", nil, aliasOnly, "concatenate")
+		m.AddCommand(alias, cmd, "", nil, aliasOnly, "concatenate", "")
 	}
 	return m
 }
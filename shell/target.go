@@ -0,0 +1,180 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pokanop/nostromo/config"
+	"github.com/pokanop/nostromo/model"
+	"github.com/spf13/cobra"
+)
+
+// StartupFilename is a shell startup file relative to the home directory
+type StartupFilename struct {
+	// Name of the file relative to the home directory
+	Name string
+
+	// Preferred files get the nostromo section
+	Preferred bool
+
+	// ConfigDir relative to the home directory, the file is created if
+	// missing as long as this directory exists
+	ConfigDir string
+}
+
+// Target integrates nostromo with a shell
+//
+// Each target supplies its own startup file discovery, alias syntax and
+// completion generator. Commands from the manifest are always written in
+// POSIX shell syntax, targets decide how to run them.
+type Target interface {
+	// Shell this target integrates with
+	Shell() Shell
+
+	// Names of the shell executable used to detect the running shell
+	Names() []string
+
+	// StartupFiles to search for and update
+	StartupFiles() []StartupFilename
+
+	// Comment a line using the shell's comment syntax
+	Comment(line string) string
+
+	// Source lines added to the nostromo section of startup files
+	Source(m *model.Manifest) string
+
+	// Completion script for a cobra command
+	Completion(cmd *cobra.Command) (string, error)
+
+	// CommandCompletion script for a manifest command
+	CommandCompletion(cmd *model.Command) (string, error)
+
+	// WrapperFunc for nostromo itself
+	WrapperFunc() string
+
+	// AliasFuncs for root commands in the manifest
+	AliasFuncs(m *model.Manifest) string
+
+	// EnvBlock runs the command with the variables set, they must not leak
+	// into the shell evaluating it
+	EnvBlock(env map[string]string, cmd string) string
+
+	// DirBlock runs the command from the directory
+	DirBlock(dir, cmd string) string
+}
+
+var targets = []Target{bash{}, zsh{}, fish{}, nushell{}, powershell{}}
+
+var override *Shell
+
+// Register a target replacing any existing one for the same shell
+func Register(t Target) {
+	for i, target := range targets {
+		if target.Shell() == t.Shell() {
+			targets[i] = t
+			return
+		}
+	}
+	targets = append(targets, t)
+}
+
+// Targets that are registered
+func Targets() []Target {
+	return targets
+}
+
+// TargetFor shell or the bash target if not registered
+func TargetFor(sh Shell) Target {
+	for _, t := range targets {
+		if t.Shell() == sh {
+			return t
+		}
+	}
+	return bash{}
+}
+
+// Which shell is currently running
+//
+// The `NOSTROMO_SHELL` variable takes precedence over `SHELL` so shell
+// integrations can identify themselves, e.g., fish started from bash.
+func Which() Shell {
+	if override != nil {
+		return *override
+	}
+
+	sh := os.Getenv("NOSTROMO_SHELL")
+	if len(sh) == 0 {
+		sh = os.Getenv("SHELL")
+	}
+
+	base := filepath.Base(sh)
+	for _, t := range targets {
+		for _, name := range t.Names() {
+			if strings.HasPrefix(base, name) {
+				return t.Shell()
+			}
+		}
+	}
+	return Bash
+}
+
+// SetShell overrides the shell detected by `Which` using one of the names
+// of a registered target, e.g., `pwsh`
+func SetShell(name string) error {
+	var names []string
+	for _, t := range targets {
+		for _, n := range t.Names() {
+			if n == name {
+				sh := t.Shell()
+				override = &sh
+				return nil
+			}
+			names = append(names, n)
+		}
+	}
+	return fmt.Errorf("invalid shell, supported shells: [%s]", strings.Join(names, " "))
+}
+
+// targetForFilename returns the target that sources the startup file
+func targetForFilename(filename string) Target {
+	for _, t := range targets {
+		for _, f := range t.StartupFiles() {
+			if strings.HasSuffix(filename, f.Name) {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// startupFilenames across all targets
+func startupFilenames() []StartupFilename {
+	var filenames []StartupFilename
+	for _, t := range targets {
+		filenames = append(filenames, t.StartupFiles()...)
+	}
+	return filenames
+}
+
+// preferredFilenames across all targets
+func preferredFilenames() []string {
+	var filenames []string
+	for _, f := range startupFilenames() {
+		if f.Preferred {
+			filenames = append(filenames, f.Name)
+		}
+	}
+	return filenames
+}
+
+// IsPlainAlias returns true for root commands written as plain shell
+// aliases, their child commands can't be run
+//
+// Commands from project manifests only exist within the project so they're
+// always resolved when run.
+func IsPlainAlias(c *model.Command) bool {
+	isProject := len(c.Source()) > 0 && c.Source() != config.GlobalScope
+	return c.AliasOnly && !isProject
+}
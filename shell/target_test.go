@@ -0,0 +1,96 @@
+package shell
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWhich(t *testing.T) {
+	tests := []struct {
+		name          string
+		shell         string
+		nostromoShell string
+		want          Shell
+	}{
+		{"default", "", "", Bash},
+		{"bash", "/bin/bash", "", Bash},
+		{"zsh", "/bin/zsh", "", Zsh},
+		{"fish", "/usr/bin/fish", "", Fish},
+		{"nushell", "/usr/bin/nu", "", Nushell},
+		{"powershell", "/opt/microsoft/powershell/7/pwsh", "", PowerShell},
+		{"override", "/bin/bash", "fish", Fish},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("SHELL", tt.shell)
+			os.Setenv("NOSTROMO_SHELL", tt.nostromoShell)
+			defer os.Unsetenv("NOSTROMO_SHELL")
+			if got := Which(); got != tt.want {
+				t.Errorf("Which() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		shell Shell
+		want  Shell
+	}{
+		{"bash", Bash, Bash},
+		{"zsh", Zsh, Zsh},
+		{"fish", Fish, Fish},
+		{"nushell", Nushell, Nushell},
+		{"powershell", PowerShell, PowerShell},
+		{"unknown", Shell(-1), Bash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TargetFor(tt.shell).Shell(); got != tt.want {
+				t.Errorf("TargetFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetForFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     Shell
+	}{
+		{"profile", "/home/user/.profile", Bash},
+		{"bashrc", "/home/user/.bashrc", Bash},
+		{"zshrc", "/home/user/.zshrc", Zsh},
+		{"fish", "/home/user/.config/fish/conf.d/nostromo.fish", Fish},
+		{"nushell", "/home/user/.config/nushell/config.nu", Nushell},
+		{"powershell", "/home/user/.config/powershell/Microsoft.PowerShell_profile.ps1", PowerShell},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := targetForFilename(tt.filename)
+			if got == nil || got.Shell() != tt.want {
+				t.Errorf("targetForFilename() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := targetForFilename("/home/user/.foo"); got != nil {
+		t.Errorf("targetForFilename() = %v, want nil", got)
+	}
+}
+
+func TestSetShell(t *testing.T) {
+	defer func() { override = nil }()
+
+	if err := SetShell("invalid"); err == nil {
+		t.Errorf("SetShell() expected error but got none")
+	}
+	if err := SetShell("pwsh"); err != nil {
+		t.Errorf("SetShell() error = %v", err)
+	}
+	if got := Which(); got != PowerShell {
+		t.Errorf("Which() = %v, want %v", got, PowerShell)
+	}
+}
@@ -1,6 +1,9 @@
 package stringutil
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // SanitizeArgs expands all args by spaces and returns a slice.
 func SanitizeArgs(args []string) []string {
@@ -21,3 +24,124 @@ func SanitizeArgs(args []string) []string {
 func ContainsCaseInsensitive(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
+
+const diffContext = 3
+
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// Diff returns a unified diff of the lines from a to b or an empty string if
+// they match
+func Diff(fromName, toName, a, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	// Line numbers in a and b where each op starts
+	aLines := make([]int, len(ops)+1)
+	bLines := make([]int, len(ops)+1)
+	aLines[0], bLines[0] = 1, 1
+	var changes []int
+	for i, op := range ops {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if op.kind != '+' {
+			aLines[i+1]++
+		}
+		if op.kind != '-' {
+			bLines[i+1]++
+		}
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- " + fromName + "\n")
+	sb.WriteString("+++ " + toName + "\n")
+	for i := 0; i < len(changes); {
+		start := max(changes[i]-diffContext, 0)
+		end := min(changes[i]+diffContext+1, len(ops))
+		for i++; i < len(changes) && changes[i]-diffContext <= end; i++ {
+			end = min(changes[i]+diffContext+1, len(ops))
+		}
+
+		aStart, aCount := aLines[start], aLines[end]-aLines[start]
+		bStart, bCount := bLines[start], bLines[end]-bLines[start]
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount))
+		for _, op := range ops[start:end] {
+			sb.WriteString(string(op.kind) + op.line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// diffLines using the longest common subsequence of lines
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
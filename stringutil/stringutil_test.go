@@ -55,3 +55,26 @@ func TestSanitizeArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{"both empty", "", "", ""},
+		{"same", "foo\nbar\n", "foo\nbar\n", ""},
+		{"added to empty", "", "foo\n", "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+foo\n"},
+		{"removed all", "foo\n", "", "--- a\n+++ b\n@@ -1,1 +0,0 @@\n-foo\n"},
+		{"changed line", "foo\nbar\nbaz\n", "foo\nqux\nbaz\n", "--- a\n+++ b\n@@ -1,3 +1,3 @@\n foo\n-bar\n+qux\n baz\n"},
+		{"separate hunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "0\n2\n3\n4\n5\n6\n7\n8\n9\n11\n", "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+0\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+11\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff("a", "b", tt.a, tt.b); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
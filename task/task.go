@@ -1,7 +1,11 @@
 package task
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/pokanop/nostromo/config"
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/lint"
 	"github.com/pokanop/nostromo/log"
 	"github.com/pokanop/nostromo/model"
 	"github.com/pokanop/nostromo/pathutil"
@@ -11,18 +15,40 @@ import (
 	"github.com/pokanop/nostromo/version"
 	"github.com/shivamMg/ppds/tree"
 	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
-var ver *version.Info
+// commandNotFound exit code used by shells when there's no such command
+const commandNotFound = 127
+
+var (
+	ver   *version.Info
+	scope = config.GlobalScope
+	lock  *config.Lock
+)
 
 // SetVersion should be called before any task to ensure manifest is updated
 func SetVersion(v *version.Info) {
 	ver = v
 }
 
+// SetScope of the manifest to modify, either global or the closest local one
+func SetScope(s string) error {
+	if s != config.GlobalScope && s != config.LocalScope {
+		return fmt.Errorf("invalid scope, supported scopes: [%s %s]", config.GlobalScope, config.LocalScope)
+	}
+	scope = s
+	return nil
+}
+
 // InitConfig of nostromo config file if not already initialized
 func InitConfig() int {
+	defer unlockConfig()
 	cfg := checkConfigQuiet()
 
 	if cfg == nil {
@@ -38,7 +64,7 @@ func InitConfig() int {
 		log.Highlight("nostromo config exists, updating")
 	}
 
-	err := saveConfig(cfg, true)
+	err := saveConfig(cfg, "init", true)
 	if err != nil {
 		log.Error(err)
 		return -1
@@ -49,12 +75,18 @@ func InitConfig() int {
 
 // DestroyConfig deletes nostromo config file
 func DestroyConfig() int {
+	defer unlockConfig()
 	cfg := checkConfig()
 	if cfg == nil {
 		return -1
 	}
 
-	err := cfg.Delete()
+	err := config.NewHistory(config.HistoryPath).Record(cfg.Path(), "destroy")
+	if err != nil {
+		log.Warningf("unable to record history: %s\n", err)
+	}
+
+	err = cfg.Delete()
 	if err != nil {
 		log.Error(err)
 		return -1
@@ -71,9 +103,70 @@ func DestroyConfig() int {
 	return 0
 }
 
+// LintManifest reports problems in the manifest, returning non-zero if any
+// errors are found, or any warnings when strict, so it can be used in CI
+func LintManifest(asJSON, strict bool, ignore []string) int {
+	var cfg *config.Config
+	if asJSON {
+		// Errors are reported as json and warnings skipped so the output can
+		// always be parsed
+		var err error
+		if cfg, err = parseLayeredConfig(true); err != nil {
+			printJSON(map[string]string{"error": err.Error()})
+			return -1
+		}
+		applySettings(cfg.Manifest())
+	} else if cfg = checkLayeredConfig(); cfg == nil {
+		return -1
+	}
+
+	issues := lint.Manifest(cfg.Manifest(), ignore)
+
+	if asJSON {
+		if issues == nil {
+			issues = []*lint.Issue{}
+		}
+		if !printJSON(issues) {
+			return -1
+		}
+	} else {
+		for _, issue := range issues {
+			log.Print(issue, "\n")
+		}
+	}
+
+	if lint.HasErrors(issues) || (strict && len(issues) > 0) {
+		return -1
+	}
+	return 0
+}
+
+// printJSON indented returning false if it can't be marshalled
+func printJSON(v interface{}) bool {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	log.Print(string(b), "\n")
+	return true
+}
+
+// ShowSchema of manifests in format as JSON Schema
+func ShowSchema(format string) int {
+	b, err := config.SchemaJSON(format)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Print(string(b))
+	return 0
+}
+
 // ShowConfig for nostromo config file
 func ShowConfig(asJSON bool, asYAML bool, asTree bool) int {
-	cfg := checkConfig()
+	cfg := checkLayeredConfig()
 	if cfg == nil {
 		return -1
 	}
@@ -132,6 +225,7 @@ func ShowConfig(asJSON bool, asYAML bool, asTree bool) int {
 
 // SetConfig updates properties for nostromo settings
 func SetConfig(key, value string) int {
+	defer unlockConfig()
 	cfg := checkConfig()
 	if cfg == nil {
 		return -1
@@ -143,7 +237,7 @@ func SetConfig(key, value string) int {
 		return -1
 	}
 
-	err = saveConfig(cfg, false)
+	err = saveConfig(cfg, fmt.Sprintf("set %s %s", key, value), false)
 	if err != nil {
 		log.Error(err)
 		return -1
@@ -154,6 +248,7 @@ func SetConfig(key, value string) int {
 
 // GetConfig reads properties from nostromo settings
 func GetConfig(key string) int {
+	defer unlockConfig()
 	cfg := checkConfig()
 	if cfg == nil {
 		return -1
@@ -163,243 +258,1321 @@ func GetConfig(key string) int {
 	return 0
 }
 
-// GenerateCompletions for all manifest commands and nostromo itself.
-func GenerateCompletions(cmd *cobra.Command) int {
-	// Generate completions for nostromo
-	s, err := shell.Completion(cmd)
+// ImportManifest grafts commands from another manifest under a namespace
+func ImportManifest(path, namespace, policy string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	other, err := config.Parse(path)
 	if err != nil {
+		log.Error(err)
 		return -1
 	}
-	log.Print(s)
 
-	cfg := checkConfigQuiet()
-	if cfg == nil {
-		return 0
+	m := cfg.Manifest()
+
+	conflicts := m.Conflicts(other.Manifest(), namespace)
+	if len(conflicts) > 0 {
+		log.Warningf("found %d conflicting commands: %s\n", len(conflicts), strings.Join(conflicts, ", "))
+		if len(policy) == 0 {
+			policies := append(model.SupportedImportPolicies(), "abort")
+			policy = policies[prompt.Choose("Choose how to resolve conflicts (abort)", policies, len(policies)-1)]
+			if policy == "abort" {
+				log.Highlight("import aborted")
+				return -1
+			}
+		}
+	} else if len(policy) == 0 {
+		policy = model.MergePolicy
 	}
 
-	// Generate completions for manifest commands
-	completions, err := shell.ManifestCompletion(cfg.Manifest())
+	err = m.Import(other.Manifest(), namespace, policy)
 	if err != nil {
-		return 0
+		log.Error(err)
+		return -1
 	}
 
-	for _, completion := range completions {
-		log.Print(completion)
+	err = saveConfig(cfg, fmt.Sprintf("import %s", path), false)
+	if err != nil {
+		log.Error(err)
+		return -1
 	}
 
+	log.Highlightf("imported %d commands from %s\n", len(other.Manifest().Commands), path)
 	return 0
 }
 
-// AddInteractive adds a command or substitution through user prompts
-func AddInteractive() int {
-	log.Highlight("Awesome! Let's add a new command or substitution to nostromo.")
-	log.Regularf("Follow the prompts below to get started.\n\n")
+// ImportAliases adds aliases and one line functions from shell startup files
+// to the manifest, optionally grouping them by prefix and commenting out the
+// originals
+//
+// Aliases are grouped under the name given for their prefix in names, or the
+// prefix itself unless it's an executable or builtin the group would shadow.
+func ImportAliases(group bool, names map[string]string, comment, yes bool) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
 
-	log.Regular("A nostromo command is a powerful shell alias that can be used to run one or many scoped commands.\n" +
-		"Substitutions are scoped so sub commands all inherit them. You can have nostromo swap arguments on the command\n" +
-		"line when it sees them simplifying your workflow.")
-	isCmd := prompt.Choose("Choose what you would like to add (command)", []string{"command", "substitution"}, 0) == 0
-	if !isCmd {
-		log.Highlight("\nGreat, let's add a substitution to an existing command.\n")
-	} else {
-		log.Highlight("\nGreat, let's build a new command.\n")
+	aliases := importableAliases(cfg.Manifest())
+	if len(aliases) == 0 {
+		log.Highlight("no aliases found to import")
+		return 0
 	}
 
-	if isCmd {
-		log.Regular("A key path is a dot '.' delimited path to where you want to add your command.\n" +
-			"Leave this blank if you want to add this to the root of the command tree.\n")
-		keypath := prompt.String("Enter a key path (e.g., 'foo.bar') to attach your command (root)", "")
+	log.Highlightf("found %d aliases to import\n", len(aliases))
+	for _, a := range aliases {
+		log.Fields(a)
+	}
 
-		log.Regular("\nBy default the command you provide is expected to run in the shell. However, nostromo provides the\n" +
-			"functionality to run code as well in some popular languages.")
-		languages := shell.SupportedLanguages()
-		language := languages[prompt.Choose("Choose a language to run your command (sh)", languages, 0)]
-		var cmd string
-		var snippet string
-		if language == "sh" {
-			cmd = prompt.StringRequired("Enter the shell command (e.g., 'echo foo') to run")
-		} else {
-			snippet = prompt.StringRequired("Enter a single line code snippet to run")
-		}
-		alias := prompt.StringRequired("Enter the alias or shortcut (e.g., 'foo') to use")
-		description := prompt.String("Enter a description (e.g., 'prints foo') for your command", "")
+	// Don't keep other nostromo processes waiting on the prompt, the
+	// manifest is read again once it's locked
+	unlockConfig()
+	if !yes && !prompt.Confirm("Import these aliases into nostromo (y/N)", false) {
+		log.Highlight("import aborted")
+		return -1
+	}
 
-		log.Regular("\nCommands added to nostromo can be composed to build declarative tools effectively. However, if you\n" +
-			"just want to use a boring old alias you can do that as well and manage them from nostromo.\n")
-		aliasOnly := prompt.Confirm("Create a standard alias - say no :) (y/N)", false)
-		var mode string
-		if !aliasOnly {
-			log.Highlight("\nGlad to see you're creating a nostromo command.\n")
-			log.Regular("Now you can run this command in several different modes. By default, commands are \"concatenated\"\n" +
-				"together so as nostromo walks the key path it builds up a final command to run.\n\n" +
-				"However, you can choose to run a command \"independently\", which effectively adds a ';' after the command\n" +
-				"to indicate to the shell to run separately. Or even \"exclusively\" which will ignore parent commands\n" +
-				"and only run this one. The flexibility is provided to meet most needs.")
-			modes := model.SupportedModes()
-			mode = modes[prompt.Choose("Choose a command mode to use (concatenate)", modes, 0)]
-		}
-		if len(keypath) == 0 {
-			keypath = alias
-		} else {
-			keypath = strings.Join([]string{keypath, alias}, ".")
-		}
-		log.Highlight("\nCreating command...\n")
+	cfg = checkConfig()
+	if cfg == nil {
+		return -1
+	}
+	m := cfg.Manifest()
+	aliases = importableAliases(m)
 
-		return AddCommand(keypath, cmd, description, snippet, language, aliasOnly, mode)
+	groups := map[string][]*shell.Alias{"": aliases}
+	if group || len(names) > 0 {
+		groups = shell.GroupAliases(aliases, names)
+		warnShadowedPrefixes(groups[""], names)
 	}
 
-	log.Regularf("A key path is a dot '.' delimited path to where you want to add your command.\n")
-	log.Regular("Substitutions are added to an existing command node so you must specify where you would like to add it.\n")
-	keypath := prompt.StringRequired("Enter a key path (e.g., 'foo.bar') to attach your command")
+	for groupName, grouped := range groups {
+		for _, a := range grouped {
+			description := fmt.Sprintf("imported from %s", a.Path)
+			prefix := a.Prefix()
+			name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(a.Command), prefix))
 
-	log.Regular("\nThe original value is generally a longer string that you want to substitute with a shorthand version.\n" +
-		"These might be long arguments that you want to sub out when you invoke your commands simplifying the call.\n")
-	sub := prompt.StringRequired("Enter the original value")
+			var err error
+			if len(groupName) > 0 && len(name) > 0 {
+				if m.Find(groupName) == nil {
+					_, err = m.AddCommand(groupName, prefix, "", &model.Code{}, false, model.ConcatenateMode.String(), "")
+				}
+				if err == nil {
+					_, err = m.AddCommand(keypath.KeyPath([]string{groupName, a.Name}), name, description, &model.Code{}, false, model.ConcatenateMode.String(), "")
+				}
+			} else {
+				_, err = m.AddCommand(a.Name, a.Command, description, &model.Code{}, !a.Function, "", "")
+			}
+			if err != nil {
+				log.Error(err)
+				return -1
+			}
+		}
+	}
 
-	log.Regular("\nThe substitution is the value you will use on the CLI to run your nostromo command.\n" +
-		"It's the shorter version of what you want nostromo to swap out into the actual call.\n")
-	alias := prompt.StringRequired("Enter the substitution")
-	log.Highlight("\nAdding substitution...\n")
+	err := saveConfig(cfg, "import aliases", true)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
 
-	return AddSubstitution(keypath, sub, alias)
+	// Only comment out aliases once they're safely in the manifest
+	if comment {
+		err = shell.CommentAliases(aliases)
+		if err != nil {
+			log.Error(err)
+			return -1
+		}
+	}
+
+	log.Highlightf("imported %d aliases\n", len(aliases))
+	return 0
 }
 
-// AddCommand to the manifest
-func AddCommand(keyPath, command, description, code, language string, aliasOnly bool, mode string) int {
-	cfg := checkConfig()
-	if cfg == nil {
-		return -1
+// importableAliases from startup files that aren't already commands
+func importableAliases(m *model.Manifest) []*shell.Alias {
+	var aliases []*shell.Alias
+	for _, a := range shell.FindAliases() {
+		if m.Find(a.Name) != nil || a.Name == "nostromo" {
+			log.Debugf("skipping existing command %s\n", a.Name)
+			continue
+		}
+		aliases = append(aliases, a)
 	}
+	return aliases
+}
 
-	m := cfg.Manifest()
+// warnShadowedPrefixes left ungrouped since they need a group name
+func warnShadowedPrefixes(ungrouped []*shell.Alias, names map[string]string) {
+	counts := map[string]int{}
+	for _, a := range ungrouped {
+		counts[a.Prefix()]++
+	}
 
-	snippet := &model.Code{
-		Language: language,
-		Snippet:  code,
+	var prefixes []string
+	for prefix, count := range counts {
+		if _, ok := names[prefix]; !ok && count > 1 && shell.IsShadowed(prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		log.Warningf("not grouping %s aliases since it would shadow the %s command, name the group with --group-name %s=<name>\n", prefix, prefix, prefix)
 	}
+}
 
-	_, err := m.AddCommand(keyPath, command, description, snippet, aliasOnly, mode)
+// EditManifest in $EDITOR validating changes before saving them
+//
+// A temp copy is edited so the manifest is only replaced once it parses,
+// errors are added as comments at the top and the editor is reopened.
+func EditManifest() int {
+	path, err := editPath()
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
 
-	cmd := m.Find(keyPath)
-	if cmd == nil {
-		log.Error("unable to find newly created command")
+	original, err := ioutil.ReadFile(pathutil.Abs(path))
+	if err != nil {
+		log.Error(err)
+		if os.IsNotExist(err) {
+			log.Info("unable to open config file, be sure to run `nostromo init` if you haven't already")
+		}
 		return -1
 	}
 
-	err = saveConfig(cfg, false)
+	// Compared against the edits to summarize changed commands
+	before := model.NewManifest()
+	if c, err := config.Parse(path); err == nil {
+		before = c.Manifest()
+	}
+
+	f, err := ioutil.TempFile("", "nostromo-*"+filepath.Ext(path))
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
+	tmp := f.Name()
+	f.Close()
 
-	logFields(cmd, m.Config.Verbose)
-	return 0
-}
+	edited, cfg, ok := editManifest(tmp, original)
+	if !ok {
+		log.Highlightf("discarded changes, edits were left in %s\n", tmp)
+		return -1
+	}
+	os.Remove(tmp)
 
-// RemoveCommand from the manifest
-func RemoveCommand(keyPath string) int {
-	cfg := checkConfig()
-	if cfg == nil {
+	if string(edited) == string(original) {
+		log.Highlight("no changes to manifest")
+		return 0
+	}
+
+	logChanges(before, cfg.Manifest())
+	if !prompt.Confirm("Save these changes (Y/n)", true) {
+		log.Highlight("discarded changes")
+		return 0
+	}
+
+	defer unlockConfig()
+	if !lockConfig(false) {
 		return -1
 	}
 
-	_, err := cfg.Manifest().RemoveCommand(keyPath)
-	if err != nil {
-		log.Error(err)
+	// Other nostromo commands could have changed it while editing
+	current, err := ioutil.ReadFile(pathutil.Abs(path))
+	if err != nil || string(current) != string(original) {
+		if err := ioutil.WriteFile(tmp, edited, 0600); err == nil {
+			log.Errorf("manifest changed while editing, edits were left in %s\n", tmp)
+		} else {
+			log.Error("manifest changed while editing")
+		}
 		return -1
 	}
 
-	err = saveConfig(cfg, false)
+	applySettings(cfg.Manifest())
+	err = saveConfig(config.NewConfig(path, cfg.Manifest()), "edit", true)
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
 
+	log.Highlightf("saved manifest %s\n", path)
 	return 0
 }
 
-// AddSubstitution to the manifest
-func AddSubstitution(keyPath, name, alias string) int {
+// logChanges to commands made by editing the manifest
+func logChanges(before, after *model.Manifest) {
+	added, removed, changed := before.Changes(after)
+	if len(added)+len(removed)+len(changed) == 0 {
+		log.Highlight("no changes to commands, only settings or formatting")
+		return
+	}
+
+	for _, keyPath := range added {
+		log.Regularf("added %s\n", keyPath)
+	}
+	for _, keyPath := range removed {
+		log.Regularf("removed %s\n", keyPath)
+	}
+	for _, keyPath := range changed {
+		log.Regularf("changed %s\n", keyPath)
+	}
+}
+
+// ConvertManifest to another file format replacing the original file
+func ConvertManifest(format string) int {
+	defer unlockConfig()
 	cfg := checkConfig()
 	if cfg == nil {
 		return -1
 	}
 
-	m := cfg.Manifest()
-
-	err := m.AddSubstitution(keyPath, name, alias)
+	path, err := config.PathForFormat(cfg.Path(), format)
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
 
-	err = saveConfig(cfg, false)
-	if err != nil {
-		log.Error(err)
+	if f, _ := config.FormatForPath(cfg.Path()); f == format {
+		log.Highlightf("manifest is already %s\n", format)
+		return 0
+	}
+
+	if _, err := os.Stat(pathutil.Abs(path)); err == nil {
+		log.Errorf("manifest already exists at %s\n", path)
 		return -1
 	}
 
-	logFields(m.Find(keyPath), m.Config.Verbose)
-	return 0
-}
+	// Both manifests are recorded together so undo restores the original
+	// and removes the converted one
+	operation := fmt.Sprintf("convert --to %s", format)
+	err = config.NewHistory(config.HistoryPath).Record(path, operation, cfg.Path())
+	if err != nil {
+		log.Warningf("unable to record history: %s\n", err)
+	}
 
-// RemoveSubstitution from the manifest
-func RemoveSubstitution(keyPath, alias string) int {
-	cfg := checkConfig()
-	if cfg == nil {
+	err = writeConfig(config.NewConfig(path, cfg.Manifest()), false)
+	if err != nil {
+		log.Error(err)
 		return -1
 	}
 
-	err := cfg.Manifest().RemoveSubstitution(keyPath, alias)
+	err = cfg.Delete()
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
 
-	err = saveConfig(cfg, false)
+	log.Highlightf("converted %s to %s\n", cfg.Path(), path)
+	return 0
+}
+
+// ShowHistory of manifest changes, newest first
+func ShowHistory() int {
+	snapshots, err := config.NewHistory(config.HistoryPath).Snapshots()
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
 
+	if len(snapshots) == 0 {
+		log.Highlight("no manifest history found")
+		return 0
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		log.Fields(snapshots[i])
+	}
 	return 0
 }
 
-// EvalString returns a command that can be used with `eval`
-func EvalString(args []string) int {
-	log.SetEcho(true)
+// Undo the latest manifest change
+func Undo() int {
+	return moveHistory("undid %s (%s)\n", (*config.History).Undo)
+}
 
-	cfg := checkConfig()
-	if cfg == nil {
+// Redo the latest undone manifest change
+func Redo() int {
+	return moveHistory("redid %s (%s)\n", (*config.History).Redo)
+}
+
+// RestoreSnapshot of the manifest from history
+func RestoreSnapshot(id string) int {
+	return moveHistory("restored manifest from before %s (%s)\n", func(h *config.History) (*config.Snapshot, error) {
+		return h.Restore(id)
+	})
+}
+
+// moveHistory through undo, redo or restore while holding the lock
+func moveHistory(format string, move func(h *config.History) (*config.Snapshot, error)) int {
+	defer unlockConfig()
+	if !lockConfig(false) {
 		return -1
 	}
 
-	m := cfg.Manifest()
-
-	language, cmd, err := m.ExecutionString(stringutil.SanitizeArgs(args))
+	s, err := move(config.NewHistory(config.HistoryPath))
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
 
-	cmdStr, err := shell.EvalString(cmd, language, m.Config.Verbose)
-	if err != nil {
+	log.Highlightf(format, s.Operation, s.ID)
+
+	// Startup files that embed commands are kept in sync like any change
+	m := startupManifest(model.NewManifest())
+	applySettings(m)
+	if err := shell.Sync(m); err != nil {
 		log.Error(err)
 		return -1
 	}
-
-	log.Print(cmdStr)
 	return 0
 }
 
-// Find matching commands and substitutions
-func Find(name string) int {
-	cfg := checkConfig()
-	if cfg == nil {
+// ListBackups of shell startup files, newest first
+func ListBackups() int {
+	checkLayeredConfigQuiet()
+
+	for _, path := range shell.ModifiedStartupFiles() {
+		log.Warningf("%s was edited outside nostromo since the last commit\n", path)
+	}
+
+	backups, err := shell.Backups()
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if len(backups) == 0 {
+		log.Highlight("no backups found")
+		return 0
+	}
+
+	for i := len(backups) - 1; i >= 0; i-- {
+		log.Fields(backups[i])
+	}
+	return 0
+}
+
+// DiffBackup shows changes made to a shell startup file since the backup
+func DiffBackup(id string) int {
+	checkLayeredConfigQuiet()
+
+	diff, err := shell.DiffBackup(id)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if len(diff) == 0 {
+		log.Highlight("no changes since backup")
+		return 0
+	}
+
+	log.Print(diff)
+	return 0
+}
+
+// Trust project manifests in dir so they're layered over the global one
+func Trust(dir string) int {
+	defer unlockConfig()
+	if !lockConfig(false) {
+		return -1
+	}
+
+	path := config.FindPath(filepath.Join(dir, config.LocalFilename))
+	if info, err := os.Stat(pathutil.Abs(path)); err != nil || info.IsDir() {
+		log.Errorf("no project manifest found in %s\n", dir)
+		return -1
+	}
+
+	if err := trustDir(dir); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlightf("trusted %s\n", pathutil.Abs(dir))
+	return 0
+}
+
+// Untrust project manifests in dir so they're ignored again
+func Untrust(dir string) int {
+	defer unlockConfig()
+	if !lockConfig(false) {
+		return -1
+	}
+
+	trust, err := config.LoadTrust(config.TrustPath)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if !trust.Remove(dir) {
+		log.Errorf("%s is not trusted\n", dir)
+		return -1
+	}
+
+	if err := saveTrust(trust); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlightf("untrusted %s\n", pathutil.Abs(dir))
+	return 0
+}
+
+// ShowTrusted directories whose project manifests are honoured
+func ShowTrusted() int {
+	trust, err := config.LoadTrust(config.TrustPath)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	dirs := trust.Dirs()
+	if len(dirs) == 0 {
+		log.Regular("no trusted directories")
+		return 0
+	}
+	for _, dir := range dirs {
+		log.Regular(dir)
+	}
+	return 0
+}
+
+// trustDir and update startup files with its root commands
+func trustDir(dir string) error {
+	trust, err := config.LoadTrust(config.TrustPath)
+	if err != nil {
+		return err
+	}
+	trust.Add(dir)
+	return saveTrust(trust)
+}
+
+// saveTrust and update startup files since root commands may have changed
+func saveTrust(trust *config.Trust) error {
+	if err := trust.Save(); err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseTrusted(config.FindPath(config.Path), trust)
+	if err != nil {
+		// Nothing to commit before nostromo is initialized
+		return nil
+	}
+	return shell.Commit(cfg.Manifest())
+}
+
+// RestoreBackup of a shell startup file
+func RestoreBackup(id string) int {
+	defer unlockConfig()
+	if !lockConfig(false) {
+		return -1
+	}
+	checkLayeredConfigQuiet()
+
+	b, err := shell.RestoreBackup(id)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlightf("restored %s from %s\n", b.Path, b.ID)
+	return 0
+}
+
+// ExportManifest prints manifest commands as a standalone file in the given format
+func ExportManifest(format string) int {
+	cfg := checkLayeredConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	if !shell.IsSupportedExportFormat(format) {
+		log.Errorf("invalid format, supported formats: %s\n", strings.Join(shell.ExportFormats(), ", "))
+		return -1
+	}
+
+	s, err := shell.Export(cfg.Manifest(), format)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Print(s)
+	return 0
+}
+
+// GenerateCompletions for all manifest commands and nostromo itself.
+func GenerateCompletions(cmd *cobra.Command, sh string) int {
+	if len(sh) > 0 {
+		if err := shell.SetShell(sh); err != nil {
+			log.Error(err)
+			return -1
+		}
+	}
+
+	// Generate completions for nostromo
+	s, err := shell.Completion(cmd.Root())
+	if err != nil {
+		return -1
+	}
+	log.Print(s)
+
+	// Root commands from every trusted project are defined up front and
+	// resolved against the current directory when run
+	cfg, err := config.ParseTrusted(config.FindPath(config.Path), loadTrust(true))
+	if err != nil {
+		return 0
+	}
+	applySettings(cfg.Manifest())
+
+	// Generate completions for manifest commands
+	completions, err := shell.ManifestCompletion(cfg.Manifest())
+	if err != nil {
+		return 0
+	}
+
+	for _, completion := range completions {
+		log.Print(completion)
+	}
+
+	return 0
+}
+
+// AddInteractive adds a command or substitution through user prompts
+func AddInteractive() int {
+	log.Highlight("Awesome! Let's add a new command or substitution to nostromo.")
+	log.Regularf("Follow the prompts below to get started.\n\n")
+
+	log.Regular("A nostromo command is a powerful shell alias that can be used to run one or many scoped commands.\n" +
+		"Substitutions are scoped so sub commands all inherit them. You can have nostromo swap arguments on the command\n" +
+		"line when it sees them simplifying your workflow.")
+	isCmd := prompt.Choose("Choose what you would like to add (command)", []string{"command", "substitution"}, 0) == 0
+	if !isCmd {
+		log.Highlight("\nGreat, let's add a substitution to an existing command.\n")
+	} else {
+		log.Highlight("\nGreat, let's build a new command.\n")
+	}
+
+	if isCmd {
+		log.Regular("A key path is a dot '.' delimited path to where you want to add your command.\n" +
+			"Leave this blank if you want to add this to the root of the command tree.\n")
+		keypath := prompt.String("Enter a key path (e.g., 'foo.bar') to attach your command (root)", "")
+
+		log.Regular("\nBy default the command you provide is expected to run in the shell. However, nostromo provides the\n" +
+			"functionality to run code as well in some popular languages.")
+		languages := SupportedLanguages()
+		language := languages[prompt.Choose("Choose a language to run your command (sh)", languages, 0)]
+		var cmd string
+		var snippet string
+		var script string
+		if language != "sh" {
+			log.Regular("\nCode can be a single line snippet or a script file for anything longer, arguments are passed to\n" +
+				"scripts as argv. Scripts are copied into the nostromo scripts directory.")
+			if prompt.Choose("Choose how to provide the code (snippet)", []string{"snippet", "script"}, 0) == 1 {
+				script = prompt.StringRequired("Enter the path to the script file (e.g., './scripts/foo.py')")
+			} else {
+				snippet = prompt.StringRequired("Enter a single line code snippet to run")
+			}
+		} else {
+			cmd = prompt.StringRequired("Enter the shell command (e.g., 'echo foo') to run")
+		}
+		alias := prompt.StringRequired("Enter the alias or shortcut (e.g., 'foo') to use")
+		description := prompt.String("Enter a description (e.g., 'prints foo') for your command", "")
+
+		log.Regular("\nCommands run from the current directory by default. You can provide a working directory instead\n" +
+			"and nostromo will run the command from there. Sub commands inherit the directory as well.\n")
+		dir := prompt.String("Enter a working directory (e.g., '~/src/foo') for your command (current)", "")
+
+		log.Regular("\nCommands added to nostromo can be composed to build declarative tools effectively. However, if you\n" +
+			"just want to use a boring old alias you can do that as well and manage them from nostromo.\n")
+		aliasOnly := prompt.Confirm("Create a standard alias - say no :) (y/N)", false)
+		var mode string
+		if !aliasOnly {
+			log.Highlight("\nGlad to see you're creating a nostromo command.\n")
+			log.Regular("Now you can run this command in several different modes. By default, commands are \"concatenated\"\n" +
+				"together so as nostromo walks the key path it builds up a final command to run.\n\n" +
+				"However, you can choose to run a command \"independently\", which effectively adds a ';' after the command\n" +
+				"to indicate to the shell to run separately. Or even \"exclusively\" which will ignore parent commands\n" +
+				"and only run this one. The flexibility is provided to meet most needs.")
+			modes := model.SupportedModes()
+			mode = modes[prompt.Choose("Choose a command mode to use (concatenate)", modes, 0)]
+		}
+		if len(keypath) == 0 {
+			keypath = alias
+		} else {
+			keypath = strings.Join([]string{keypath, alias}, ".")
+		}
+		log.Highlight("\nCreating command...\n")
+
+		if len(script) > 0 {
+			return AddScriptCommand(keypath, script, description, language, false, aliasOnly, mode, dir)
+		}
+		return AddCommand(keypath, cmd, description, snippet, language, aliasOnly, mode, dir)
+	}
+
+	log.Regularf("A key path is a dot '.' delimited path to where you want to add your command.\n")
+	log.Regular("Substitutions are added to an existing command node so you must specify where you would like to add it.\n")
+	keypath := prompt.StringRequired("Enter a key path (e.g., 'foo.bar') to attach your command")
+
+	log.Regular("\nThe original value is generally a longer string that you want to substitute with a shorthand version.\n" +
+		"These might be long arguments that you want to sub out when you invoke your commands simplifying the call.\n")
+	sub := prompt.StringRequired("Enter the original value")
+
+	log.Regular("\nThe substitution is the value you will use on the CLI to run your nostromo command.\n" +
+		"It's the shorter version of what you want nostromo to swap out into the actual call.\n")
+	alias := prompt.StringRequired("Enter the substitution")
+	log.Highlight("\nAdding substitution...\n")
+
+	return AddSubstitution(keypath, sub, alias)
+}
+
+// SupportedLanguages for code snippets including those declared in the manifest
+func SupportedLanguages() []string {
+	checkLayeredConfigQuiet()
+	return shell.SupportedLanguages()
+}
+
+// IsSupportedLanguage returns true if the language is built in or declared in
+// the manifest and false otherwise
+func IsSupportedLanguage(language string) bool {
+	checkLayeredConfigQuiet()
+	return shell.IsSupportedLanguage(language)
+}
+
+// AddCommand to the manifest
+func AddCommand(keyPath, command, description, code, language string, aliasOnly bool, mode, dir string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	snippet := &model.Code{
+		Language: language,
+		Snippet:  code,
+	}
+
+	return addCommand(cfg, keyPath, command, description, snippet, aliasOnly, mode, dir)
+}
+
+// AddScriptCommand to the manifest running the script file at path
+//
+// The language is inferred from the script if not provided.
+func AddScriptCommand(keyPath, path, description, language string, link, aliasOnly bool, mode, dir string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	code, err := importScript(cfg, keyPath, path, language, link)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	return addCommand(cfg, keyPath, "", description, code, aliasOnly, mode, dir)
+}
+
+func addCommand(cfg *config.Config, keyPath, command, description string, code *model.Code, aliasOnly bool, mode, dir string) int {
+	m := cfg.Manifest()
+
+	_, err := m.AddCommand(keyPath, command, description, code, aliasOnly, mode, dir)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	cmd := m.Find(keyPath)
+	if cmd == nil {
+		log.Error("unable to find newly created command")
+		return -1
+	}
+
+	err = saveConfig(cfg, fmt.Sprintf("add cmd %s", keyPath), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	logFields(cmd, m.Config.Verbose)
+	return 0
+}
+
+// importScript at path for the command at key path returning code that runs it
+//
+// Scripts for the global manifest are copied, or linked if requested, into the
+// scripts directory beside it so the manifest doesn't depend on where they came
+// from. Local manifests reference scripts relative to themselves instead.
+func importScript(cfg *config.Config, keyPath, path, language string, link bool) (*model.Code, error) {
+	src := pathutil.Abs(path)
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("script is not a file: %s", path)
+	}
+
+	if len(language) == 0 {
+		language, err = shell.ScriptLanguage(src)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !shell.IsSupportedLanguage(language) {
+		return nil, fmt.Errorf("invalid language, must be in [%s]", strings.Join(shell.SupportedLanguages(), ","))
+	}
+
+	base := filepath.Dir(pathutil.Abs(cfg.Path()))
+	if scope == config.LocalScope {
+		script, err := filepath.Rel(base, src)
+		if err != nil {
+			script = src
+		}
+		return &model.Code{Language: language, Script: filepath.ToSlash(script)}, nil
+	}
+
+	script := filepath.Join(config.ScriptsDir, keyPath+filepath.Ext(src))
+	dst := filepath.Join(base, script)
+	if dst != src {
+		if err := pathutil.EnsurePath(filepath.Dir(dst)); err != nil {
+			return nil, err
+		}
+		// Replace rather than write through any existing link
+		if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if link {
+			err = os.Symlink(src, dst)
+		} else {
+			var b []byte
+			if b, err = ioutil.ReadFile(src); err == nil {
+				err = pathutil.WriteFile(dst, b, info.Mode())
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to import script: %s", err)
+		}
+	}
+
+	return &model.Code{Language: language, Script: filepath.ToSlash(script)}, nil
+}
+
+// SetCommand fields on an existing command leaving others unchanged
+//
+// A script in the update is imported like it is when adding a command, link
+// links it instead of copying it.
+func SetCommand(keyPath string, update *model.CommandUpdate, link bool) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Manifest()
+	if m.Find(keyPath) == nil {
+		log.Error("command not found")
+		return -1
+	}
+
+	if update.Script != nil && len(*update.Script) > 0 {
+		var language string
+		if update.Language != nil {
+			language = *update.Language
+		}
+		code, err := importScript(cfg, keyPath, *update.Script, language, link)
+		if err != nil {
+			log.Error(err)
+			return -1
+		}
+		update.Script, update.Language = &code.Script, &code.Language
+	}
+
+	if err := m.UpdateCommand(keyPath, update); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	err := saveConfig(cfg, fmt.Sprintf("set cmd %s", keyPath), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	logFields(m.Find(keyPath), m.Config.Verbose)
+	return 0
+}
+
+// SetCommandInteractive prompts for command fields using current values as
+// defaults
+func SetCommandInteractive(keyPath string) int {
+	// Unlock while prompting, SetCommand locks again to save
+	cfg := checkConfig()
+	unlockConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	cmd := cfg.Manifest().Find(keyPath)
+	if cmd == nil {
+		log.Error("command not found")
+		return -1
+	}
+
+	log.Highlightf("Editing %s, press enter to keep the current values in parentheses.\n", keyPath)
+
+	update := &model.CommandUpdate{}
+	if cmd.Code != nil && len(cmd.Code.Script) > 0 {
+		// Scripts are kept unless another one is entered, which is imported
+		// with its language inferred
+		script := prompt.String(fmt.Sprintf("Enter the script file to run (%s)", cmd.Code.Script), cmd.Code.Script)
+		if script != cmd.Code.Script {
+			update.Script = &script
+		}
+	} else {
+		language := "sh"
+		if cmd.Code != nil && len(cmd.Code.Snippet) > 0 && len(cmd.Code.Language) > 0 {
+			language = cmd.Code.Language
+		}
+		languages := shell.SupportedLanguages()
+		current := 0
+		for i, l := range languages {
+			if l == language {
+				current = i
+			}
+		}
+		language = languages[prompt.Choose(fmt.Sprintf("Choose a language to run your command (%s)", language), languages, current)]
+
+		update.Language = &language
+		if language == "sh" {
+			name := prompt.String(fmt.Sprintf("Enter the shell command to run (%s)", cmd.Name), cmd.Name)
+			empty := ""
+			update.Name, update.Snippet = &name, &empty
+		} else {
+			var snippet string
+			if cmd.Code != nil {
+				snippet = cmd.Code.Snippet
+			}
+			snippet = prompt.String(fmt.Sprintf("Enter a single line code snippet to run (%s)", snippet), snippet)
+			update.Snippet = &snippet
+		}
+	}
+
+	description := prompt.String(fmt.Sprintf("Enter a description for your command (%s)", cmd.Description), cmd.Description)
+	dir := prompt.String(fmt.Sprintf("Enter a working directory for your command (%s)", cmd.Dir), cmd.Dir)
+	modes := model.SupportedModes()
+	mode := modes[prompt.Choose(fmt.Sprintf("Choose a command mode to use (%s)", cmd.Mode), modes, int(cmd.Mode))]
+	update.Description, update.Dir, update.Mode = &description, &dir, &mode
+
+	log.Highlight("\nUpdating command...\n")
+	return SetCommand(keyPath, update, false)
+}
+
+// RemoveCommand from the manifest
+func RemoveCommand(keyPath string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	_, err := cfg.Manifest().RemoveCommand(keyPath)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	err = saveConfig(cfg, fmt.Sprintf("remove cmd %s", keyPath), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	return 0
+}
+
+// MoveCommand tree to another key path
+func MoveCommand(from, to string) int {
+	return relocateCommand("mv", from, to, (*model.Manifest).MoveCommand)
+}
+
+// CopyCommand tree to another key path
+func CopyCommand(from, to string) int {
+	return relocateCommand("cp", from, to, (*model.Manifest).CopyCommand)
+}
+
+// relocateCommand with fn committing shell aliases if root commands changed
+func relocateCommand(operation, from, to string, fn func(*model.Manifest, string, string) (bool, error)) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Manifest()
+	isRoot, err := fn(m, from, to)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	commit := isRoot && scope == config.GlobalScope
+	err = saveConfig(cfg, fmt.Sprintf("%s %s %s", operation, from, to), commit)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if cmd := m.Find(to); cmd != nil {
+		logFields(cmd, m.Config.Verbose)
+	}
+	return 0
+}
+
+// AddSubstitution to the manifest
+func AddSubstitution(keyPath, name, alias string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Manifest()
+
+	err := m.AddSubstitution(keyPath, name, alias)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	err = saveConfig(cfg, fmt.Sprintf("add sub %s %s", keyPath, alias), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	logFields(m.Find(keyPath), m.Config.Verbose)
+	return 0
+}
+
+// RemoveSubstitution from the manifest
+func RemoveSubstitution(keyPath, alias string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	err := cfg.Manifest().RemoveSubstitution(keyPath, alias)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	err = saveConfig(cfg, fmt.Sprintf("remove sub %s %s", keyPath, alias), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	return 0
+}
+
+// AddParam to the manifest
+func AddParam(keyPath, name, paramType, def, description string, values []string, optional bool) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Manifest()
+
+	param := &model.Param{
+		Name:        name,
+		Type:        paramType,
+		Default:     def,
+		Description: description,
+		Values:      values,
+		Optional:    optional,
+	}
+
+	err := m.AddParam(keyPath, param)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	err = saveConfig(cfg, fmt.Sprintf("add param %s %s", keyPath, name), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	logFields(m.Find(keyPath), m.Config.Verbose)
+	return 0
+}
+
+// RemoveParam from the manifest
+func RemoveParam(keyPath, name string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	err := cfg.Manifest().RemoveParam(keyPath, name)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	err = saveConfig(cfg, fmt.Sprintf("remove param %s %s", keyPath, name), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	return 0
+}
+
+// AddEnv variable to the manifest
+func AddEnv(keyPath, name, value string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Manifest()
+
+	err := m.AddEnv(keyPath, name, value)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	err = saveConfig(cfg, fmt.Sprintf("add env %s %s", keyPath, name), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	logFields(m.Find(keyPath), m.Config.Verbose)
+	return 0
+}
+
+// RemoveEnv variable from the manifest
+func RemoveEnv(keyPath, name string) int {
+	defer unlockConfig()
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	err := cfg.Manifest().RemoveEnv(keyPath, name)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	err = saveConfig(cfg, fmt.Sprintf("remove env %s %s", keyPath, name), false)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	return 0
+}
+
+// EvalString returns a command that can be used with `eval`
+func EvalString(args []string) int {
+	log.SetEcho(true)
+
+	cfg := checkLayeredConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Manifest()
+
+	args = stringutil.SanitizeArgs(args)
+
+	// Root commands are defined for every trusted project, let the shell
+	// fall back to an executable when there's none in this directory
+	if len(args) > 0 && m.Find(keypath.Keys(args[0])[0]) == nil {
+		log.Debugf("no command %s found from this directory\n", args[0])
+		return commandNotFound
+	}
+
+	e, err := m.Execution(args)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if e.Run {
+		log.Print(shell.RunString(args))
+		return 0
+	}
+
+	cmdStr, err := shell.EvalString(e, m.Config.Verbose)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Print(cmdStr)
+	return 0
+}
+
+// Run command from manifest directly returning its exit code
+func Run(args []string) int {
+	cfg := checkLayeredConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Manifest()
+
+	e, err := m.Execution(stringutil.SanitizeArgs(args))
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	code, err := shell.Run(e, m.Config.Verbose)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	return code
+}
+
+// Explain how args resolve to the command to run without running it
+//
+// Each step is printed in order, if one fails the steps before it are shown
+// along with the failure.
+func Explain(args []string, asJSON bool) int {
+	cfg := checkLayeredConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Manifest()
+
+	args = stringutil.SanitizeArgs(args)
+	x, err := m.Explain(args)
+	if err == nil {
+		if x.Execution.Run {
+			x.Eval = shell.RunString(args)
+		} else if x.Eval, err = shell.ExplainString(x.Execution); err != nil {
+			x.Fail(model.EvalStep, err)
+		}
+	}
+
+	if asJSON {
+		if !printJSON(x) || len(x.Failed) > 0 {
+			return -1
+		}
+		return 0
+	}
+
+	steps := []struct {
+		name  string
+		print func()
+	}{
+		{model.KeyPathStep, func() {
+			consumed := len(x.Args) - len(x.Remaining)
+			if len(x.Source) > 0 {
+				log.Regularf("   %s matched %s using %d of %d arguments from %s\n", strings.Join(x.Args, " "), x.KeyPath, consumed, len(x.Args), x.Source)
+			} else {
+				log.Regularf("   %s matched %s using %d of %d arguments\n", strings.Join(x.Args, " "), x.KeyPath, consumed, len(x.Args))
+			}
+		}},
+		{model.ArgumentsStep, func() {
+			if len(x.Remaining) == 0 {
+				log.Regular("   none left over")
+			}
+			for i, arg := range x.Remaining {
+				log.Regularf("   $%d %s\n", i+1, arg)
+			}
+		}},
+		{model.SubstitutionsStep, func() {
+			if len(x.Substitutions) == 0 {
+				log.Regular("   none applied")
+			}
+			for _, sub := range x.Substitutions {
+				log.Regularf("   %s -> %s from %s\n", sub.Arg, sub.Value, sub.KeyPath)
+			}
+		}},
+		{model.ParamsStep, func() {
+			if len(x.Params) == 0 {
+				log.Regular("   none declared")
+			}
+			for _, p := range x.Params {
+				log.Regularf("   %s\n", p)
+			}
+		}},
+		{model.ScopesStep, func() {
+			for _, scope := range x.Scopes {
+				log.Regularf("   %s\n", scope)
+			}
+		}},
+		{model.CommandStep, func() {
+			log.Regularf("   %s\n", x.Command)
+			if x.Execution.Command != x.Command {
+				log.Regularf("   bound to %s\n", x.Execution.Command)
+			}
+			if len(x.Execution.Args) > 0 {
+				log.Regularf("   passing %s\n", strings.Join(x.Execution.Args, " "))
+			}
+			if len(x.Execution.Language) > 0 && x.Execution.Language != "sh" {
+				log.Regularf("   running %s code\n", x.Execution.Language)
+			}
+			if len(x.Execution.Dir) > 0 {
+				log.Regularf("   from %s\n", x.Execution.Dir)
+			}
+			var env []string
+			for k, v := range x.Execution.Env {
+				env = append(env, fmt.Sprintf("%s=%s", k, v))
+			}
+			sort.Strings(env)
+			if len(env) > 0 {
+				log.Regularf("   with %s\n", strings.Join(env, " "))
+			}
+		}},
+		{model.EvalStep, func() {
+			log.Regularf("   %s\n", x.Eval)
+		}},
+	}
+
+	for i, step := range steps {
+		log.Highlightf("%d. %s\n", i+1, step.name)
+		if step.name == x.Failed {
+			log.Error(x.Error)
+			return -1
+		}
+		step.print()
+	}
+
+	return 0
+}
+
+// Find matching commands and substitutions
+func Find(name string) int {
+	cfg := checkLayeredConfig()
+	if cfg == nil {
 		return -1
 	}
 
@@ -456,37 +1629,304 @@ func checkConfig() *config.Config {
 	return checkConfigCommon(false)
 }
 
+func checkLayeredConfigQuiet() *config.Config {
+	return checkLayeredConfigCommon(true)
+}
+
+func checkLayeredConfig() *config.Config {
+	return checkLayeredConfigCommon(false)
+}
+
+func checkLayeredConfigCommon(quiet bool) *config.Config {
+	cfg, err := parseLayeredConfig(quiet)
+	if err != nil {
+		if !quiet {
+			log.Error(err)
+			if os.IsNotExist(err) {
+				log.Info("unable to open config file, be sure to run `nostromo init` if you haven't already")
+			}
+		}
+		return nil
+	}
+
+	applySettings(cfg.Manifest())
+
+	return cfg
+}
+
+// parseLayeredConfig from the global manifest and trusted project manifests
+// in the working directory, untrusted ones are warned about unless quiet
+func parseLayeredConfig(quiet bool) (*config.Config, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	trust := loadTrust(quiet)
+	cfg, err := config.ParseLayered(config.FindPath(config.Path), dir, trust)
+	if err != nil {
+		return nil, err
+	}
+
+	if !quiet {
+		for _, path := range config.UntrustedPaths(dir, trust) {
+			log.Warning(fmt.Sprintf("ignoring untrusted project manifest %s, trust it with 'nostromo trust %s' to use it", path, filepath.Dir(path)))
+		}
+	}
+
+	return cfg, nil
+}
+
+// loadTrust of project directories, nothing is trusted if it can't be read
+func loadTrust(quiet bool) *config.Trust {
+	trust, err := config.LoadTrust(config.TrustPath)
+	if err != nil && !quiet {
+		log.Warningf("unable to read trusted directories: %s\n", err)
+	}
+	return trust
+}
+
 func checkConfigCommon(quiet bool) *config.Config {
-	cfg, err := config.Parse(config.Path)
+	if !lockConfig(quiet) {
+		return nil
+	}
+
+	if scope == config.LocalScope {
+		return checkLocalConfigCommon(quiet)
+	}
+
+	cfg, err := config.Parse(config.FindPath(config.Path))
+	if err != nil {
+		if !quiet {
+			log.Error(err)
+			if os.IsNotExist(err) {
+				log.Info("unable to open config file, be sure to run `nostromo init` if you haven't already")
+			}
+		}
+		return nil
+	}
+
+	applySettings(cfg.Manifest())
+
+	return cfg
+}
+
+// checkLocalConfigCommon uses the closest local manifest or creates a new
+// one in the current directory
+func checkLocalConfigCommon(quiet bool) *config.Config {
+	dir, err := os.Getwd()
+	if err != nil {
+		if !quiet {
+			log.Error(err)
+		}
+		return nil
+	}
+
+	paths := config.FindLocalPaths(dir)
+	if len(paths) == 0 {
+		return config.NewConfig(filepath.Join(dir, config.LocalFilename), model.NewManifest())
+	}
+
+	cfg, err := config.Parse(paths[len(paths)-1])
 	if err != nil {
 		if !quiet {
 			log.Error(err)
-			log.Info("unable to open config file, be sure to run `nostromo init` if you haven't already")
 		}
 		return nil
 	}
 
-	log.SetVerbose(cfg.Manifest().Config.Verbose)
+	applySettings(cfg.Manifest())
 
 	return cfg
 }
 
-func saveConfig(cfg *config.Config, commit bool) error {
+// applySettings from the manifest config
+func applySettings(m *model.Manifest) {
+	log.SetVerbose(m.Config.Verbose)
+	shell.SetBackups(m.Config.BackupDir, m.Config.BackupCount)
+	shell.SetLanguages(m.Config.Languages)
+}
+
+// lockConfig so concurrent nostromo processes modify manifests and startup
+// files one at a time, the lock is skipped before nostromo is initialized
+func lockConfig(quiet bool) bool {
+	if lock != nil {
+		return true
+	}
+	if _, err := os.Stat(filepath.Dir(pathutil.Abs(config.LockPath))); err != nil {
+		return true
+	}
+
+	l, err := config.AcquireLock(config.LockPath, config.LockTimeout)
+	if err != nil {
+		if !quiet {
+			log.Error(err)
+		}
+		return false
+	}
+	lock = l
+
+	return true
+}
+
+// unlockConfig acquired by checkConfig
+func unlockConfig() {
+	if lock == nil {
+		return
+	}
+
+	if err := lock.Unlock(); err != nil {
+		log.Debugf("unable to unlock: %s\n", err)
+	}
+	lock = nil
+}
+
+// saveConfig after recording a snapshot of the previous manifest so the
+// operation can be undone
+func saveConfig(cfg *config.Config, operation string, commit bool) error {
+	err := config.NewHistory(config.HistoryPath).Record(cfg.Path(), operation)
+	if err != nil {
+		log.Warningf("unable to record history: %s\n", err)
+	}
+
+	return writeConfig(cfg, commit)
+}
+
+// writeConfig without recording history, callers changing more than one
+// manifest record them together
+func writeConfig(cfg *config.Config, commit bool) error {
 	m := cfg.Manifest()
 	m.Version = ver.SemVer
 
+	created := !cfg.Exists()
 	err := cfg.Save()
 	if err != nil {
 		return err
 	}
 
+	if created && scope == config.LocalScope {
+		if err := trustDir(filepath.Dir(pathutil.Abs(cfg.Path()))); err != nil {
+			log.Warningf("unable to trust project manifest: %s\n", err)
+		}
+	}
+
+	// Startup files that embed commands are kept in sync with every change
 	if commit {
-		err = shell.Commit(m)
+		err = shell.Commit(startupManifest(m))
+	} else {
+		err = shell.Sync(startupManifest(m))
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// startupManifest with root commands from the global manifest and every
+// trusted project manifest so they're defined no matter where the shell
+// starts, m is used if they can't be parsed
+func startupManifest(m *model.Manifest) *model.Manifest {
+	if cfg, err := config.ParseTrusted(config.FindPath(config.Path), loadTrust(true)); err == nil {
+		return cfg.Manifest()
+	}
+	return m
+}
+
+// editorCommentPrefix marks errors added to the top of edited manifests
+const editorCommentPrefix = "# nostromo: "
+
+// editPath of the manifest to edit for the current scope, unlike
+// checkConfig it doesn't parse the manifest so broken ones can be fixed
+func editPath() (string, error) {
+	if scope != config.LocalScope {
+		return config.FindPath(config.Path), nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	paths := config.FindLocalPaths(dir)
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no project manifest found from %s", dir)
+	}
+	return paths[len(paths)-1], nil
+}
+
+// editManifest contents in the temp file at path until they parse or the
+// user gives up, returning the edited contents and parsed config
+func editManifest(path string, contents []byte) ([]byte, *config.Config, bool) {
+	for {
+		if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+			log.Error(err)
+			return nil, nil, false
+		}
+
+		if err := openEditor(path); err != nil {
+			log.Error(err)
+			return nil, nil, false
+		}
+
+		b, err := ioutil.ReadFile(path)
 		if err != nil {
-			return err
+			log.Error(err)
+			return nil, nil, false
+		}
+		contents = stripEditorComments(b)
+		if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+			log.Error(err)
+			return nil, nil, false
+		}
+
+		cfg, err := config.Parse(path)
+		if err == nil {
+			return contents, cfg, true
+		}
+
+		log.Error(err)
+		if !prompt.Confirm("Reopen the editor to fix it (Y/n)", true) {
+			return nil, nil, false
+		}
+
+		var comments []string
+		for _, line := range strings.Split(err.Error(), "\n") {
+			comments = append(comments, editorCommentPrefix+strings.TrimSpace(line))
 		}
+		comments = append(comments, editorCommentPrefix+"fix the manifest and save, these comments are removed and not counted in line numbers\n")
+		contents = append([]byte(strings.Join(comments, "\n")), contents...)
 	}
+}
+
+// stripEditorComments added to the top of the manifest
+func stripEditorComments(b []byte) []byte {
+	lines := strings.SplitAfter(string(b), "\n")
+	i := 0
+	for i < len(lines) && strings.HasPrefix(lines[i], editorCommentPrefix) {
+		i++
+	}
+	return []byte(strings.Join(lines[i:], ""))
+}
 
+// openEditor from $VISUAL or $EDITOR on path, defaulting to vi
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if len(editor) == 0 {
+		editor = os.Getenv("EDITOR")
+	}
+	if len(editor) == 0 {
+		editor = "vi"
+	}
+
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %s", editor, err)
+	}
 	return nil
 }
 